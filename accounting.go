@@ -0,0 +1,99 @@
+package acos
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+)
+
+// RequestCounter counts the AWS Cost Explorer API requests, which are charged for each request. See EnableRequestCounter.
+type RequestCounter struct {
+	mu     sync.Mutex
+	counts map[string]int // map[action]count
+}
+
+// add counts a request of the IAM action, e.g. "ce:GetCostAndUsage".
+func (c *RequestCounter) add(action string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[action]++
+}
+
+// Count returns the total number of the requests.
+func (c *RequestCounter) Count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, v := range c.counts {
+		n += v
+	}
+	return n
+}
+
+// Counts returns the number of the requests for each IAM action, e.g. "ce:GetCostAndUsage".
+func (c *RequestCounter) Counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]int, len(c.counts))
+	for k, v := range c.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// Price returns the price of the requests in USD.
+func (c *RequestCounter) Price() float64 {
+	return float64(c.Count()) * CeRequestPrice
+}
+
+// EnableRequestCounter wraps the AWS Cost Explorer API clients to count the requests which reach AWS and succeed,
+// i.e. the billable requests. Every page of a paginated API is a request.
+// It should be called after Configure which recreates the clients, and before EnableCache so that the responses
+// from the cache are not counted.
+func EnableRequestCounter() *RequestCounter {
+	c := &RequestCounter{counts: make(map[string]int)}
+	ceClient = &countingCeClient{api: ceClient, counter: c}
+	ceForecastClient = &countingCeForecastClient{api: ceForecastClient, counter: c}
+	ceDimensionClient = &countingCeDimensionClient{api: ceDimensionClient, counter: c}
+	return c
+}
+
+type countingCeClient struct {
+	api     CeGetCostAndUsageAPI
+	counter *RequestCounter
+}
+
+func (c *countingCeClient) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	res, err := c.api.GetCostAndUsage(ctx, params, optFns...)
+	if err == nil {
+		c.counter.add("ce:GetCostAndUsage")
+	}
+	return res, err
+}
+
+type countingCeForecastClient struct {
+	api     CeGetCostForecastAPI
+	counter *RequestCounter
+}
+
+func (c *countingCeForecastClient) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	res, err := c.api.GetCostForecast(ctx, params, optFns...)
+	if err == nil {
+		c.counter.add("ce:GetCostForecast")
+	}
+	return res, err
+}
+
+type countingCeDimensionClient struct {
+	api     CeGetDimensionValuesAPI
+	counter *RequestCounter
+}
+
+func (c *countingCeDimensionClient) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	res, err := c.api.GetDimensionValues(ctx, params, optFns...)
+	if err == nil {
+		c.counter.add("ce:GetDimensionValues")
+	}
+	return res, err
+}
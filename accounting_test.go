@@ -0,0 +1,59 @@
+package acos
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+)
+
+func TestWithMock_EnableRequestCounter(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	fail := false
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		if fail {
+			return nil, errors.New("error")
+		}
+		// Two pages.
+		if params.NextPageToken == nil {
+			return &costexplorer.GetCostAndUsageOutput{NextPageToken: aws.String("next")}, nil
+		}
+		return &costexplorer.GetCostAndUsageOutput{
+			ResultsByTime: dailyResults("123456789012", time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), asOf, func(day time.Time) float64 { return 1.5 }),
+		}, nil
+	})
+	defer func(c CeGetCostForecastAPI, d CeGetDimensionValuesAPI) {
+		ceForecastClient, ceDimensionClient = c, d
+	}(ceForecastClient, ceDimensionClient)
+	counter := EnableRequestCounter()
+	if err := EnableCache(t.TempDir(), time.Hour, "default/111111111111/ce"); err != nil {
+		t.Fatalf("EnableCache() error = %v", err)
+	}
+
+	accounts := Accounts{"123456789012": Account{Id: toPointer("123456789012"), Name: toPointer("test")}}
+	for i := 0; i < 2; i++ {
+		// The second run is served from the cache.
+		if _, err := GetCosts(context.Background(), accounts, NewGetCostsOption(asOf)); err != nil {
+			t.Fatalf("GetCosts() error = %v", err)
+		}
+	}
+	// The failed requests are not billable.
+	fail = true
+	if _, err := GetCosts(context.Background(), accounts, NewGetCostsOption(asOf.AddDate(0, 0, -1))); err == nil {
+		t.Fatal("GetCosts() error = nil, want an error")
+	}
+
+	if got := counter.Count(); got != 2 {
+		t.Errorf("Count() = %d, want 2", got)
+	}
+	if got, want := counter.Counts(), map[string]int{"ce:GetCostAndUsage": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Counts() = %v, want %v", got, want)
+	}
+	if got := counter.Price(); got != 0.02 {
+		t.Errorf("Price() = %f, want 0.02", got)
+	}
+}
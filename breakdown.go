@@ -0,0 +1,158 @@
+package acos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+const (
+	// BreakdownByService breaks down the costs by AWS services, e.g. "Amazon Elastic Compute Cloud - Compute".
+	BreakdownByService = "SERVICE"
+	// BreakdownByUsageType breaks down the costs by usage types, e.g. "APN1-BoxUsage:t3.micro".
+	BreakdownByUsageType = "USAGE_TYPE"
+)
+
+// BreakdownQuery represents the scope of GetCostBreakdown.
+type BreakdownQuery struct {
+	AccountID string
+	GroupBy   string // Either one of BreakdownByService or BreakdownByUsageType.
+	Service   string // Optional - Only breaks down the costs of the service.
+}
+
+// BreakdownItem represents a cost of an account for a dimension value, such as a service.
+// The AccountName of the Cost is always empty.
+type BreakdownItem struct {
+	Key string // The dimension value, e.g. the service name.
+	Cost
+}
+
+// GetCostBreakdown returns the costs of an account broken down by the dimension of the query.
+// The items are sorted by the cost of this month in descending order.
+func GetCostBreakdown(ctx context.Context, q BreakdownQuery, opt AcosGetCostsOption) ([]BreakdownItem, error) {
+	if len(q.AccountID) == 0 {
+		return nil, fmt.Errorf("%w to retrieve cost: GetCostBreakdown requires an account ID", ErrNoAccounts)
+	}
+	if q.GroupBy != BreakdownByService && q.GroupBy != BreakdownByUsageType {
+		return nil, fmt.Errorf("error invalid dimension '%s' to break down costs", q.GroupBy)
+	}
+	ceOpt := acosOptToCostExplorerOpt(opt, []string{q.AccountID})
+	ceOpt.GroupBy = []types.GroupDefinition{
+		{
+			Type: types.GroupDefinitionTypeDimension,
+			Key:  aws.String(q.GroupBy),
+		},
+	}
+	if len(q.Service) > 0 {
+		ceOpt.Filter = acosOptToFilter(opt, []string{q.AccountID}, types.Expression{
+			Dimensions: &types.DimensionValues{
+				Key:    types.DimensionService,
+				Values: []string{q.Service},
+			},
+		})
+	}
+
+	days := opt.days()
+	items := make(map[string]*BreakdownItem)
+	var nextToken *string
+	for {
+		ceOpt.NextPageToken = nextToken
+
+		out, err := ceClient.GetCostAndUsage(ctx, &ceOpt)
+		if err != nil {
+			return nil, classifyError("ce:GetCostAndUsage", err)
+		}
+		for _, r := range out.ResultsByTime {
+			for _, g := range r.Groups {
+				grp := Group(g)
+				key := grp.Keys[0]
+				if _, ok := items[key]; !ok {
+					items[key] = &BreakdownItem{Key: key, Cost: newCost(q.AccountID, "", days)}
+				}
+				items[key].add(*r.TimePeriod.Start, *r.TimePeriod.End, grp.getAmount(), opt)
+			}
+		}
+
+		nextToken = out.NextPageToken
+		if nextToken == nil {
+			break
+		}
+	}
+
+	result := make([]BreakdownItem, 0, len(items))
+	for _, item := range items {
+		item.computeChanges()
+		result = append(result, *item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].AmountThisMonth != result[j].AmountThisMonth {
+			return result[i].AmountThisMonth > result[j].AmountThisMonth
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// GetServiceCosts returns the costs of the accounts broken down by AWS services, including the daily costs.
+// The items are sorted by the account IDs and then the service names, and the AccountName of the Cost is always empty.
+// Unlike GetCosts, it fails when retrieving the costs fails for any of the accounts.
+func GetServiceCosts(ctx context.Context, accounts Accounts, opt AcosGetCostsOption) ([]BreakdownItem, error) {
+	accountIds := accounts.AccountIds()
+	if len(accountIds) == 0 && !opt.AllLinkedAccounts {
+		return nil, fmt.Errorf("%w to retrieve cost: GetServiceCosts requires at least one account in Accounts", ErrNoAccounts)
+	}
+	if opt.AllLinkedAccounts {
+		accountIds = nil
+	}
+	_, results, errs := getCostAndUsageInBatches(ctx, accountIds, func(batch []string) costexplorer.GetCostAndUsageInput {
+		return serviceCostsInput(opt, batch)
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	days := opt.days()
+	items := make(map[[2]string]*BreakdownItem) // map[[accountId, service]]
+	for _, batch := range results {
+		for _, r := range batch {
+			for _, g := range r.Groups {
+				grp := Group(g)
+				key := [2]string{grp.getAccountId(), grp.Keys[1]}
+				if _, ok := items[key]; !ok {
+					items[key] = &BreakdownItem{Key: key[1], Cost: newCost(key[0], "", days)}
+				}
+				items[key].add(*r.TimePeriod.Start, *r.TimePeriod.End, grp.getAmount(), opt)
+			}
+		}
+	}
+
+	result := make([]BreakdownItem, 0, len(items))
+	for _, item := range items {
+		item.computeChanges()
+		result = append(result, *item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].AccountID != result[j].AccountID {
+			return result[i].AccountID < result[j].AccountID
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// serviceCostsInput returns the GetCostAndUsageInput param of GetServiceCosts, which groups the costs by the accounts and the services.
+func serviceCostsInput(opt AcosGetCostsOption, accountIds []string) costexplorer.GetCostAndUsageInput {
+	in := acosOptToCostExplorerOpt(opt, accountIds)
+	in.GroupBy = append(in.GroupBy, types.GroupDefinition{
+		Type: types.GroupDefinitionTypeDimension,
+		Key:  aws.String(BreakdownByService),
+	})
+	return in
+}
@@ -0,0 +1,132 @@
+package acos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+func TestWithMock_GetCostBreakdown(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	var gotInput *costexplorer.GetCostAndUsageInput
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		t.Helper()
+		gotInput = params
+		results := dailyResults("APN1-BoxUsage:t3.micro", time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), asOf, func(day time.Time) float64 { return 1 })
+		for i, r := range dailyResults("APN1-EBS:VolumeUsage.gp3", time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), asOf, func(day time.Time) float64 { return 2 }) {
+			results[i].Groups = append(results[i].Groups, r.Groups...)
+		}
+		return &costexplorer.GetCostAndUsageOutput{ResultsByTime: results}, nil
+	})
+
+	got, err := GetCostBreakdown(context.Background(), BreakdownQuery{
+		AccountID: "123456789012",
+		GroupBy:   BreakdownByUsageType,
+		Service:   "Amazon Elastic Compute Cloud - Compute",
+	}, NewGetCostsOption(asOf))
+	if err != nil {
+		t.Fatalf("GetCostBreakdown() error = %v", err)
+	}
+	if *gotInput.GroupBy[0].Key != BreakdownByUsageType {
+		t.Errorf("GetCostBreakdown() GroupBy = %v, want %v", *gotInput.GroupBy[0].Key, BreakdownByUsageType)
+	}
+	if f := gotInput.Filter.And[len(gotInput.Filter.And)-1].Dimensions; f == nil || f.Key != types.DimensionService {
+		t.Errorf("GetCostBreakdown() doesn't filter by the service")
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetCostBreakdown() returned %d items, want 2", len(got))
+	}
+	if got[0].Key != "APN1-EBS:VolumeUsage.gp3" || got[0].AmountThisMonth != 34 || got[0].AccountID != "123456789012" {
+		t.Errorf("GetCostBreakdown() [0] = %v %v, want the most expensive usage type first", got[0].Key, got[0].AmountThisMonth)
+	}
+	if got[1].Daily[len(got[1].Daily)-1].Amount != 1 {
+		t.Errorf("GetCostBreakdown() [1] the latest daily cost = %v, want 1", got[1].Daily[len(got[1].Daily)-1].Amount)
+	}
+
+	if _, err := GetCostBreakdown(context.Background(), BreakdownQuery{AccountID: "123456789012", GroupBy: "REGION"}, NewGetCostsOption(asOf)); err == nil {
+		t.Errorf("GetCostBreakdown() error = nil, want error for an unsupported dimension")
+	}
+}
+
+func TestWithMock_GetCostBreakdown_withoutExcludeOptions(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	opt := NewGetCostsOption(asOf)
+	opt.ExcludeCredit, opt.ExcludeUpfront, opt.ExcludeRefund, opt.ExcludeSupport = false, false, false, false
+	q := BreakdownQuery{AccountID: "111111111111", GroupBy: BreakdownByUsageType, Service: "Amazon EC2"}
+
+	in := acosOptToCostExplorerOpt(opt, []string{q.AccountID})
+	if in.Filter == nil || in.Filter.And != nil {
+		t.Fatalf("acosOptToCostExplorerOpt() Filter = %v, want the account filter only", in.Filter)
+	}
+	var gotInput *costexplorer.GetCostAndUsageInput
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		gotInput = params
+		return &costexplorer.GetCostAndUsageOutput{}, nil
+	})
+	if _, err := GetCostBreakdown(context.Background(), q, opt); err != nil {
+		t.Fatalf("GetCostBreakdown() error = %v", err)
+	}
+	if f := gotInput.Filter; f.Dimensions != nil || len(f.And) != 2 || f.And[0].Dimensions.Key != ceCostGroupBy || f.And[1].Dimensions.Key != types.DimensionService {
+		t.Errorf("GetCostBreakdown() Filter = %v, want the account filter and the service filter in And", f)
+	}
+
+	UseDataSource(NewLineItemSource([]LineItem{
+		{AccountID: "111111111111", Date: "2023-07-01", RecordType: "Usage", Service: "Amazon EC2", UsageType: "BoxUsage", Amount: 1},
+		{AccountID: "111111111111", Date: "2023-07-01", RecordType: "Usage", Service: "Amazon S3", UsageType: "TimedStorage", Amount: 2},
+		{AccountID: "222222222222", Date: "2023-07-01", RecordType: "Usage", Service: "Amazon EC2", UsageType: "BoxUsage", Amount: 4},
+	}))
+	got, err := GetCostBreakdown(context.Background(), q, opt)
+	if err != nil {
+		t.Fatalf("GetCostBreakdown() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Key != "BoxUsage" || got[0].AmountThisMonth != 1 {
+		t.Errorf("GetCostBreakdown() = %v, want the EC2 costs of 111111111111 only", got)
+	}
+}
+
+func TestWithMock_GetServiceCosts(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	UseDataSource(NewLineItemSource([]LineItem{
+		{AccountID: "111111111111", Date: "2023-07-01", RecordType: "Usage", Service: "Amazon S3", Amount: 1},
+		{AccountID: "111111111111", Date: "2023-07-17", RecordType: "Usage", Service: "Amazon EC2", Amount: 2},
+		{AccountID: "111111111111", Date: "2023-07-17", RecordType: "Usage", Service: "Amazon EC2", Amount: 3},
+		{AccountID: "222222222222", Date: "2023-06-30", RecordType: "Usage", Service: "Amazon EC2", Amount: 4},
+		{AccountID: "333333333333", Date: "2023-07-01", RecordType: "Usage", Service: "Amazon EC2", Amount: 5},
+	}))
+	accounts := Accounts{
+		"111111111111": Account{Id: aws.String("111111111111"), Name: aws.String("dev")},
+		"222222222222": Account{Id: aws.String("222222222222"), Name: aws.String("prod")},
+	}
+
+	got, err := GetServiceCosts(context.Background(), accounts, NewGetCostsOption(asOf))
+	if err != nil {
+		t.Fatalf("GetServiceCosts() error = %v", err)
+	}
+	want := []struct {
+		accountId, service   string
+		lastMonth, thisMonth float64
+	}{
+		{"111111111111", "Amazon EC2", 0, 5},
+		{"111111111111", "Amazon S3", 0, 1},
+		{"222222222222", "Amazon EC2", 4, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("GetServiceCosts() returned %d items, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].AccountID != w.accountId || got[i].Key != w.service || got[i].AmountLastMonth != w.lastMonth || got[i].AmountThisMonth != w.thisMonth {
+			t.Errorf("GetServiceCosts() [%d] = %s %s %v %v, want %v", i, got[i].AccountID, got[i].Key, got[i].AmountLastMonth, got[i].AmountThisMonth, w)
+		}
+	}
+	if d := got[0].Daily[daysBetween(got[0].Daily[0].Date, "2023-07-17")]; d.Amount != 5 {
+		t.Errorf("GetServiceCosts() [0] the daily cost of %s = %v, want 5", d.Date, d.Amount)
+	}
+
+	if _, err := GetServiceCosts(context.Background(), Accounts{}, NewGetCostsOption(asOf)); err == nil {
+		t.Errorf("GetServiceCosts() error = nil, want error for no accounts")
+	}
+}
@@ -0,0 +1,163 @@
+package acos
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+)
+
+// EnableCache caches the responses of the AWS Cost Explorer APIs as files in the directory, for the given TTL.
+// The Cost Explorer data is updated at most a few times a day, and every API request is charged,
+// so that it helps repeated runs not to make unnecessary requests.
+// Requests fail over to the API when the cache is not available, e.g. the directory is not writable.
+//
+// The scope identifies the credentials and the data source of the responses, e.g. the AWS profile and the caller
+// account ID, so that the responses cached for one of them are never returned for another.
+func EnableCache(dir string, ttl time.Duration, scope string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	c := &fileCache{dir: dir, ttl: ttl, scope: scope}
+	ceClient = &cachingCeClient{api: ceClient, cache: c}
+	ceForecastClient = &cachingCeForecastClient{api: ceForecastClient, cache: c}
+	ceDimensionClient = &cachingCeDimensionClient{api: ceDimensionClient, cache: c}
+	return nil
+}
+
+// DefaultCacheDir returns the default directory for EnableCache.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "acos"), nil
+}
+
+// fileCache stores the API responses as JSON files named by the hash of the scope, the API name and the request.
+type fileCache struct {
+	dir   string
+	ttl   time.Duration
+	scope string
+}
+
+type cacheEntry struct {
+	CachedAt time.Time
+	Response json.RawMessage
+}
+
+func (c *fileCache) path(api string, in any) (string, error) {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(append([]byte(c.scope+"\n"+api+"\n"), b...))
+	return filepath.Join(c.dir, hex.EncodeToString(h[:])+".json"), nil
+}
+
+// get unmarshals the cached response into `out`. It returns false when the response is not cached or has expired.
+func (c *fileCache) get(api string, in any, out any) bool {
+	p, err := c.path(api, in)
+	if err != nil {
+		return false
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil || time.Since(e.CachedAt) > c.ttl {
+		return false
+	}
+	return json.Unmarshal(e.Response, out) == nil
+}
+
+// put stores the response. It ignores errors because the cache is just an optimization.
+func (c *fileCache) put(api string, in any, out any) {
+	p, err := c.path(api, in)
+	if err != nil {
+		return
+	}
+	res, err := json.Marshal(out)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(cacheEntry{CachedAt: time.Now(), Response: res})
+	if err != nil {
+		return
+	}
+	// Write to a temporary file and rename it, not to leave a broken file.
+	// The temporary file is unique so that concurrent runs don't write to the same file.
+	tmp, err := os.CreateTemp(c.dir, filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), p)
+}
+
+type cachingCeClient struct {
+	api   CeGetCostAndUsageAPI
+	cache *fileCache
+}
+
+func (c *cachingCeClient) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	var out costexplorer.GetCostAndUsageOutput
+	if c.cache.get("GetCostAndUsage", params, &out) {
+		return &out, nil
+	}
+	res, err := c.api.GetCostAndUsage(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put("GetCostAndUsage", params, res)
+	return res, nil
+}
+
+type cachingCeForecastClient struct {
+	api   CeGetCostForecastAPI
+	cache *fileCache
+}
+
+func (c *cachingCeForecastClient) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	var out costexplorer.GetCostForecastOutput
+	if c.cache.get("GetCostForecast", params, &out) {
+		return &out, nil
+	}
+	res, err := c.api.GetCostForecast(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put("GetCostForecast", params, res)
+	return res, nil
+}
+
+type cachingCeDimensionClient struct {
+	api   CeGetDimensionValuesAPI
+	cache *fileCache
+}
+
+func (c *cachingCeDimensionClient) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	var out costexplorer.GetDimensionValuesOutput
+	if c.cache.get("GetDimensionValues", params, &out) {
+		return &out, nil
+	}
+	res, err := c.api.GetDimensionValues(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put("GetDimensionValues", params, res)
+	return res, nil
+}
@@ -0,0 +1,96 @@
+package acos
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+)
+
+func TestWithMock_EnableCache(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	api := mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		t.Helper()
+		calls++
+		return &costexplorer.GetCostAndUsageOutput{
+			ResultsByTime: dailyResults("123456789012", time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), asOf, func(day time.Time) float64 { return 1.5 }),
+		}, nil
+	})
+	ceClient = api
+	defer func(c CeGetCostForecastAPI) { ceForecastClient = c }(ceForecastClient)
+	dir := t.TempDir()
+	if err := EnableCache(dir, time.Hour, "default/111111111111/ce"); err != nil {
+		t.Fatalf("EnableCache() error = %v", err)
+	}
+
+	accounts := Accounts{"123456789012": Account{Id: toPointer("123456789012"), Name: toPointer("test")}}
+	first, err := GetCosts(context.Background(), accounts, NewGetCostsOption(asOf))
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	second, err := GetCosts(context.Background(), accounts, NewGetCostsOption(asOf))
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("GetCostAndUsage API calls = %d, want 1", calls)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("GetCosts() with cache = %v, want %v", second, first)
+	}
+
+	// Another query is not cached.
+	if _, err := GetCosts(context.Background(), accounts, NewGetCostsOption(asOf.AddDate(0, 0, -1))); err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("GetCostAndUsage API calls = %d, want 2", calls)
+	}
+
+	// The same query in another scope, e.g. of another AWS profile, is not cached.
+	ceClient = api
+	if err := EnableCache(dir, time.Hour, "other/222222222222/ce"); err != nil {
+		t.Fatalf("EnableCache() error = %v", err)
+	}
+	if _, err := GetCosts(context.Background(), accounts, NewGetCostsOption(asOf)); err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("GetCostAndUsage API calls = %d, want 3", calls)
+	}
+}
+
+func Test_fileCache_concurrentPuts(t *testing.T) {
+	c := &fileCache{dir: t.TempDir(), ttl: time.Hour}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.put("GetCostAndUsage", "in", &costexplorer.GetCostAndUsageOutput{NextPageToken: toPointer(strings.Repeat("x", i*1000))})
+		}(i)
+	}
+	wg.Wait()
+	var out costexplorer.GetCostAndUsageOutput
+	if !c.get("GetCostAndUsage", "in", &out) {
+		t.Errorf("fileCache.get() = false, want true after concurrent puts")
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(c.dir, "*.tmp")); len(tmps) > 0 {
+		t.Errorf("temporary files = %v, want none", tmps)
+	}
+}
+
+func Test_fileCache_expired(t *testing.T) {
+	c := &fileCache{dir: t.TempDir(), ttl: -time.Second}
+	c.put("GetCostAndUsage", "in", &costexplorer.GetCostAndUsageOutput{})
+	var out costexplorer.GetCostAndUsageOutput
+	if c.get("GetCostAndUsage", "in", &out) {
+		t.Errorf("fileCache.get() = true, want false for an expired response")
+	}
+}
@@ -0,0 +1,107 @@
+package acos
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ClientOptions represents the options of the AWS API clients. See Configure.
+type ClientOptions struct {
+	// MaxAttempts is the max number of attempts of an API call including the first one.
+	// The throttled and the transient errors are retried with exponential backoff and full jitter. 0 uses the SDK default.
+	MaxAttempts int
+	// MaxBackoff is the max wait time between the attempts. 0 uses the SDK default.
+	MaxBackoff time.Duration
+	// RequestsPerSecond limits the rate of the API calls, including the retries, across all the AWS APIs. 0 means no limit.
+	RequestsPerSecond float64
+}
+
+// Configure recreates the AWS API clients with the options.
+// It should be called before any other functions of this package, and before EnableCache which wraps the clients.
+func Configure(opt ClientOptions) {
+	c := cfg.Copy()
+	c.Retryer = func() aws.Retryer { return newRetryer(opt) }
+	if opt.RequestsPerSecond > 0 {
+		l := newRateLimiter(opt.RequestsPerSecond)
+		c.APIOptions = append(c.APIOptions, l.addMiddleware)
+	}
+
+	ceClient = costexplorer.NewFromConfig(c)
+	ceForecastClient = costexplorer.NewFromConfig(c)
+	ceDimensionClient = costexplorer.NewFromConfig(c)
+	organizationsClient = organizations.NewFromConfig(c)
+	orgListTagsClient = organizationsClient
+	stsClient = sts.NewFromConfig(c)
+	iamClient = iam.NewFromConfig(c)
+}
+
+// newRetryer returns the retryer of the options. It retries the throttling errors of all the AWS APIs used by acos,
+// e.g. ThrottlingException of Cost Explorer and TooManyRequestsException of AWS Organizations.
+func newRetryer(opt ClientOptions) aws.Retryer {
+	return retry.NewStandard(func(o *retry.StandardOptions) {
+		if opt.MaxAttempts > 0 {
+			o.MaxAttempts = opt.MaxAttempts
+		}
+		if opt.MaxBackoff > 0 {
+			o.MaxBackoff = opt.MaxBackoff
+		}
+		// Don't give up retrying the throttled calls when the client-side retry quota runs out,
+		// since running against large organizations makes lots of throttled calls by nature.
+		o.RateLimiter = ratelimit.None
+	})
+}
+
+// rateLimiter spaces the API calls at even intervals. It's shared by all the clients.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time // The earliest time of the next call.
+}
+
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / requestsPerSecond)}
+}
+
+// wait blocks until the next call is allowed, or until the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	if d := slot.Sub(now); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// addMiddleware adds the middleware which waits for the rate limiter. It's added after the retry middleware
+// so that every attempt is limited.
+func (l *rateLimiter) addMiddleware(stack *middleware.Stack) error {
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("AcosRateLimiter", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		if err := l.wait(ctx); err != nil {
+			return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+		}
+		return next.HandleFinalize(ctx, in)
+	}), middleware.After)
+}
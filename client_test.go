@@ -0,0 +1,52 @@
+package acos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/smithy-go"
+)
+
+func Test_newRetryer(t *testing.T) {
+	r := newRetryer(ClientOptions{MaxAttempts: 3, MaxBackoff: time.Second})
+	if r.MaxAttempts() != 3 {
+		t.Errorf("newRetryer().MaxAttempts() = %d, want 3", r.MaxAttempts())
+	}
+	for _, err := range []error{
+		&cetypes.LimitExceededException{},
+		&smithy.GenericAPIError{Code: "ThrottlingException"},
+		&orgtypes.TooManyRequestsException{},
+	} {
+		if !r.IsErrorRetryable(err) {
+			t.Errorf("newRetryer().IsErrorRetryable(%T) = false, want true", err)
+		}
+	}
+	if r.IsErrorRetryable(&orgtypes.AccountNotFoundException{}) {
+		t.Errorf("newRetryer().IsErrorRetryable(AccountNotFoundException) = true, want false")
+	}
+}
+
+func Test_rateLimiter(t *testing.T) {
+	l := newRateLimiter(100) // 10ms intervals
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first call doesn't wait.
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("rateLimiter.wait() 5 times took %v, want at least 40ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = newRateLimiter(0.1)
+	_ = l.wait(ctx) // The first call doesn't wait.
+	if err := l.wait(ctx); err == nil {
+		t.Errorf("rateLimiter.wait() should fail when the context is done")
+	}
+}
@@ -2,10 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
 	"github.com/toricls/acos"
 )
 
@@ -15,6 +23,14 @@ const ERR_INFUFFICIENT_IAM_PERMISSIONS = "Failed to perform \"%s\".  Make sure y
 type GetAccountsOption struct {
 	AccountIds []string
 	OuId       string
+
+	// The following options filter the accounts. The patterns are either a glob, e.g. "myproduct-*",
+	// or a regular expression enclosed in slashes, e.g. "/^myproduct-(dev|prod)$/".
+	AccountNamePattern        string
+	ExcludeAccountIds         []string
+	ExcludeAccountNamePattern string
+	AccountTags               map[string]string // The accounts must have all the tags.
+	ActiveOnly                bool              // Exclude the accounts which are not ACTIVE, e.g. SUSPENDED.
 }
 
 // getAccounts returns a list of AWS accounts which the caller has access to.
@@ -25,39 +41,177 @@ func getAccounts(ctx context.Context, opt GetAccountsOption) (acos.Accounts, err
 	if len(opt.AccountIds) > 0 {
 		fmt.Fprintln(os.Stderr, "Account IDs specified. Retrieving accounts information...")
 		availableAccnts, err = getAccountsByIds(ctx, opt.AccountIds)
-		if !acos.IsOrganizationEnabled(err) {
-			fmt.Fprint(os.Stderr, ERR_AWS_ORGANIZATION_NOT_ENABLED)
-		} else if !acos.HasPermissionToOrganizationsApi(err) {
-			fmt.Fprintf(os.Stderr, ERR_INFUFFICIENT_IAM_PERMISSIONS, "organizations:ListAccounts")
-		}
 	} else if len(opt.OuId) > 0 {
 		fmt.Fprintf(os.Stderr, "Retrieving AWS accounts under the OU '%s'...\n", opt.OuId)
 		availableAccnts, err = getAccountsByOu(ctx, opt.OuId)
-		if !acos.OuExists(err) {
-			// To avoid duplicated error messages, we override the AWS error by our own.
-			err = fmt.Errorf("error the OU \"%s\" doesn't exist", opt.OuId)
+		var notFound *acos.OuNotFoundError
+		if errors.As(err, &notFound) {
 			// Stop the process here and we don't fall back to using the "getCallerAccount" func
 			// because the specified OU ID is not just valid.
 			return nil, err
-		} else if !acos.IsOrganizationEnabled(err) {
-			fmt.Fprint(os.Stderr, ERR_AWS_ORGANIZATION_NOT_ENABLED)
-		} else if !acos.HasPermissionToOrganizationsApi(err) {
-			fmt.Fprintf(os.Stderr, ERR_INFUFFICIENT_IAM_PERMISSIONS, "organizations:ListAccountsForParent")
 		}
 	} else {
 		availableAccnts, err = getAccountsInOrg(ctx)
-		if !acos.IsOrganizationEnabled(err) {
-			fmt.Fprint(os.Stderr, ERR_AWS_ORGANIZATION_NOT_ENABLED)
-		} else if !acos.HasPermissionToOrganizationsApi(err) {
-			fmt.Fprintf(os.Stderr, ERR_INFUFFICIENT_IAM_PERMISSIONS, "organizations:ListAccounts")
-		}
 	}
+	printOrganizationsError(err)
 
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Falling back to using \"sts:GetCallerIdentity\" and \"iam:ListAccountAliases\" to obtain your AWS account information... ")
 		availableAccnts, err = getCallerAccount(ctx)
 	}
-	return availableAccnts, err
+	if err != nil {
+		return nil, err
+	}
+	availableAccnts, err = filterAccounts(availableAccnts, opt)
+	if err != nil || len(opt.AccountTags) == 0 {
+		return availableAccnts, err
+	}
+	tags, err := acos.ListAccountTags(ctx, availableAccnts.AccountIds())
+	if err != nil {
+		printOrganizationsError(err)
+		return nil, err
+	}
+	return filterAccountsByTags(availableAccnts, opt.AccountTags, tags), nil
+}
+
+// printOrganizationsError prints the hint to fix the error of the AWS Organizations APIs, if any.
+func printOrganizationsError(err error) {
+	var accessDenied *acos.AccessDeniedError
+	if errors.Is(err, acos.ErrOrganizationNotInUse) {
+		fmt.Fprint(os.Stderr, ERR_AWS_ORGANIZATION_NOT_ENABLED)
+	} else if errors.As(err, &accessDenied) {
+		fmt.Fprintf(os.Stderr, ERR_INFUFFICIENT_IAM_PERMISSIONS, accessDenied.Action)
+	}
+}
+
+// filterAccountsByTags returns the accounts which have all the `want` tags.
+func filterAccountsByTags(accnts acos.Accounts, want map[string]string, tags acos.AccountTags) acos.Accounts {
+	result := make(acos.Accounts, len(accnts))
+	for id, a := range accnts {
+		matched := true
+		for k, v := range want {
+			if actual, ok := tags[id][k]; !ok || actual != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result[id] = a
+		}
+	}
+	return result
+}
+
+// parseAccountTags returns the tags of the -accountTag flag value, e.g. "env=prod,team=payments".
+func parseAccountTags(value string) (map[string]string, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || len(k) == 0 {
+			return nil, fmt.Errorf("error invalid account tag '%s'. It should be 'key=value'", pair)
+		}
+		tags[k] = v
+	}
+	return tags, nil
+}
+
+// filterAccounts returns the accounts which match the name pattern, and which are not excluded by the options.
+func filterAccounts(accnts acos.Accounts, opt GetAccountsOption) (acos.Accounts, error) {
+	include, err := newNameMatcher(opt.AccountNamePattern)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := newNameMatcher(opt.ExcludeAccountNamePattern)
+	if err != nil {
+		return nil, err
+	}
+	excludedIds := make(map[string]bool, len(opt.ExcludeAccountIds))
+	for _, id := range opt.ExcludeAccountIds {
+		excludedIds[id] = true
+	}
+
+	result := make(acos.Accounts, len(accnts))
+	for id, a := range accnts {
+		name := ""
+		if a.Name != nil {
+			name = *a.Name
+		}
+		if excludedIds[id] {
+			continue
+		}
+		if opt.ActiveOnly && len(a.Status) > 0 && a.Status != types.AccountStatusActive {
+			// The accounts without the status, e.g. the caller account outside organizations, are considered active.
+			continue
+		}
+		if len(opt.AccountNamePattern) > 0 && !include(name) {
+			continue
+		}
+		if len(opt.ExcludeAccountNamePattern) > 0 && exclude(name) {
+			continue
+		}
+		result[id] = a
+	}
+	return result, nil
+}
+
+// newNameMatcher returns the func to match account names with the pattern.
+// The pattern is a regular expression when it's enclosed in slashes, otherwise it's a glob.
+func newNameMatcher(pattern string) (func(name string) bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("error invalid regular expression '%s': %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("error invalid glob pattern '%s': %w", pattern, err)
+	}
+	return func(name string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// readAccountIds returns the account IDs of the -accountIds flag value.
+// The value is either comma-separated account IDs, "@" followed by a file path, or "-" to read from `stdin`.
+// The file and stdin contain account IDs separated by commas, spaces or newlines, and lines starting with "#" are ignored.
+func readAccountIds(value string, stdin io.Reader) ([]string, error) {
+	var content []byte
+	var err error
+	switch {
+	case value == "-":
+		content, err = io.ReadAll(stdin)
+	case strings.HasPrefix(value, "@"):
+		content, err = os.ReadFile(value[1:])
+	default:
+		return parseAccountIds(value), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error failed to read account IDs: %w", err)
+	}
+
+	var accountIds []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		accountIds = append(accountIds, splitAccountIds(line)...)
+	}
+	if len(accountIds) == 0 {
+		return nil, fmt.Errorf("error no account IDs found in '%s'", value)
+	}
+	return accountIds, nil
+}
+
+// splitAccountIds returns the account IDs separated by commas or spaces, without the empty ones.
+func splitAccountIds(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
 }
 
 func getAccountsByIds(ctx context.Context, accountIds []string) (acos.Accounts, error) {
@@ -71,10 +225,7 @@ func getAccountsByIds(ctx context.Context, accountIds []string) (acos.Accounts,
 			continue
 		}
 		if a, ok := accounts[id]; ok {
-			availableAccnts[id] = acos.Account{
-				Id:   a.Id,
-				Name: a.Name,
-			}
+			availableAccnts[id] = a
 		} else {
 			fmt.Fprintf(os.Stderr, "Account ID '%s' is not found in your AWS organization\n", id)
 		}
@@ -109,30 +260,157 @@ func getCallerAccount(ctx context.Context) (acos.Accounts, error) {
 	return acosAccounts, nil
 }
 
+// accountGroups represents the groups of accounts in the interactive account selector.
+type accountGroups struct {
+	Label string            // The kind of the groups, e.g. "OU" and "tag env".
+	Names map[string]string // The group name of each account. The map key is the account ID.
+}
+
+// validateGroupBy returns an error if the value of the -groupBy flag is neither "ou" nor "tag:<key>".
+func validateGroupBy(groupBy string) error {
+	if len(groupBy) == 0 || groupBy == "ou" {
+		return nil
+	}
+	if key, ok := strings.CutPrefix(groupBy, "tag:"); ok && len(key) > 0 {
+		return nil
+	}
+	return fmt.Errorf("error invalid -groupBy '%s'. It should be either 'ou' or 'tag:<key>'", groupBy)
+}
+
+// getAccountGroups returns the groups of the accounts for the value of the -groupBy flag.
+// It returns nil when `groupBy` is empty.
+func getAccountGroups(ctx context.Context, groupBy string, accnts acos.Accounts) (*accountGroups, error) {
+	if len(groupBy) == 0 {
+		return nil, nil
+	}
+	groups := &accountGroups{Names: make(map[string]string, len(accnts))}
+	if groupBy == "ou" {
+		groups.Label = "OU"
+		ous, err := acos.ListAccountOus(ctx, accnts.AccountIds())
+		if err != nil {
+			return nil, err
+		}
+		for id, ou := range ous {
+			groups.Names[id] = ou.Name
+		}
+		return groups, nil
+	}
+	key := strings.TrimPrefix(groupBy, "tag:")
+	groups.Label = "tag " + key
+	tags, err := acos.ListAccountTags(ctx, accnts.AccountIds())
+	if err != nil {
+		return nil, err
+	}
+	for id, t := range tags {
+		groups.Names[id] = t[key]
+	}
+	return groups, nil
+}
+
+// pickerOption represents an option of the interactive account selector, which selects one or more accounts.
+type pickerOption struct {
+	Label      string
+	AccountIds []string
+	Group      bool // The option selects all the accounts in a group.
+}
+
+// pickerOptions returns the options of the interactive account selector, sorted by the account name and ID.
+// When `groups` is set, the accounts are grouped by the group names, and each group is headed by the option
+// which selects all the accounts in the group.
+func pickerOptions(accnts acos.Accounts, groups *accountGroups) []pickerOption {
+	accntIds := accnts.AccountIds()
+	sort.Slice(accntIds, func(i, j int) bool {
+		ni, nj := accountName(accnts[accntIds[i]]), accountName(accnts[accntIds[j]])
+		if ni != nj {
+			return ni < nj
+		}
+		return accntIds[i] < accntIds[j]
+	})
+
+	var opts []pickerOption
+	if groups == nil {
+		for _, id := range accntIds {
+			opts = append(opts, pickerOption{Label: accountLabel(accnts[id]), AccountIds: []string{id}})
+		}
+		return opts
+	}
+
+	members := make(map[string][]string)
+	for _, id := range accntIds {
+		name := groups.Names[id]
+		if len(name) == 0 {
+			name = "(none)"
+		}
+		members[name] = append(members[name], id)
+	}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		opts = append(opts, pickerOption{
+			Label:      fmt.Sprintf("[All in %s: %s (%d account(s))]", groups.Label, name, len(members[name])),
+			AccountIds: members[name],
+			Group:      true,
+		})
+		for _, id := range members[name] {
+			opts = append(opts, pickerOption{Label: "  " + accountLabel(accnts[id]), AccountIds: []string{id}})
+		}
+	}
+	return opts
+}
+
+// accountLabel returns the label of the account in the interactive account selector, e.g. "123456789012 - my-prod (ACTIVE)".
+func accountLabel(a acos.Account) string {
+	label := fmt.Sprintf("%s - %s", *a.Id, accountName(a))
+	if len(a.Status) > 0 {
+		label += fmt.Sprintf(" (%s)", a.Status)
+	}
+	return label
+}
+
+func accountName(a acos.Account) string {
+	if a.Name == nil {
+		return ""
+	}
+	return *a.Name
+}
+
 // promptAccountsSelection prompts the user to select AWS accounts to retrieve costs.
 // It returns an error if the `accnts` arg doesn't contain any Account.
 // If the `accnts` arg contains only one Account, it never prompts the user.
-func promptAccountsSelection(accnts acos.Accounts) (acos.Accounts, error) {
+// The accounts of the `defaults` account IDs are preselected, and the accounts are grouped by `groups` if it's set.
+func promptAccountsSelection(accnts acos.Accounts, defaults []string, groups *accountGroups) (acos.Accounts, error) {
 	if len(accnts) == 0 {
-		return nil, fmt.Errorf("error no accounts found")
+		return nil, fmt.Errorf("%w found", acos.ErrNoAccounts)
 	} else if len(accnts) == 1 {
 		// No need to prompt the user to select accounts if there is only one account.
 		return accnts, nil
 	}
 
-	opts := make([]string, len(accnts))
-	accntIds := make([]string, len(accnts))
-	i := 0
-	for _, a := range accnts {
-		opts[i] = fmt.Sprintf("%s - %s", *a.Id, *a.Name)
-		accntIds[i] = *a.Id
-		i++
+	pickerOpts := pickerOptions(accnts, groups)
+	opts := make([]string, len(pickerOpts))
+	preselected := make(map[string]bool, len(defaults))
+	for _, id := range defaults {
+		preselected[id] = true
+	}
+	var defaultIdx []int
+	for i, o := range pickerOpts {
+		opts[i] = o.Label
+		// Preselect the accounts, not the groups.
+		if !o.Group && preselected[o.AccountIds[0]] {
+			defaultIdx = append(defaultIdx, i)
+		}
 	}
 	q := &survey.MultiSelect{
 		Message:  "Select accounts:",
 		Options:  opts,
 		PageSize: 10,
 	}
+	if len(defaultIdx) > 0 {
+		q.Default = defaultIdx
+	}
 
 	var selIdx []int
 	err := survey.AskOne(
@@ -151,8 +429,9 @@ func promptAccountsSelection(accnts acos.Accounts) (acos.Accounts, error) {
 
 	result := make(acos.Accounts)
 	for _, v := range selIdx {
-		accntId := accntIds[v]
-		result[accntId] = accnts[accntId]
+		for _, accntId := range pickerOpts[v].AccountIds {
+			result[accntId] = accnts[accntId]
+		}
 	}
 	return result, nil
 }
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
 	"github.com/toricls/acos"
 )
 
@@ -50,7 +57,7 @@ func Test_selectAccounts(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := promptAccountsSelection(tt.args.accnts)
+			got, err := promptAccountsSelection(tt.args.accnts, nil, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("selectAccounts() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -61,3 +68,238 @@ func Test_selectAccounts(t *testing.T) {
 		})
 	}
 }
+
+func Test_filterAccounts(t *testing.T) {
+	accnts := acos.Accounts{
+		"111111111111": acos.Account{Id: toPointer("111111111111"), Name: toPointer("myproduct-dev")},
+		"222222222222": acos.Account{Id: toPointer("222222222222"), Name: toPointer("myproduct-prod")},
+		"333333333333": acos.Account{Id: toPointer("333333333333"), Name: toPointer("sandbox"), Status: types.AccountStatusSuspended},
+	}
+	tests := []struct {
+		name    string
+		opt     GetAccountsOption
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "no filters",
+			opt:  GetAccountsOption{},
+			want: []string{"111111111111", "222222222222", "333333333333"},
+		},
+		{
+			name: "glob",
+			opt:  GetAccountsOption{AccountNamePattern: "myproduct-*"},
+			want: []string{"111111111111", "222222222222"},
+		},
+		{
+			name: "regular expression",
+			opt:  GetAccountsOption{AccountNamePattern: "/prod$|^sand/"},
+			want: []string{"222222222222", "333333333333"},
+		},
+		{
+			name: "exclude account IDs and names",
+			opt: GetAccountsOption{
+				ExcludeAccountIds:         []string{"111111111111"},
+				ExcludeAccountNamePattern: "sand*",
+			},
+			want: []string{"222222222222"},
+		},
+		{
+			name: "active only",
+			opt:  GetAccountsOption{ActiveOnly: true},
+			want: []string{"111111111111", "222222222222"},
+		},
+		{
+			name:    "invalid regular expression",
+			opt:     GetAccountsOption{AccountNamePattern: "/[/"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid glob",
+			opt:     GetAccountsOption{ExcludeAccountNamePattern: "["},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterAccounts(accnts, tt.opt)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("filterAccounts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			ids := got.AccountIds()
+			sort.Strings(ids)
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("filterAccounts() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readAccountIds(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "accounts.txt")
+	if err := os.WriteFile(file, []byte("# production\n111111111111\n222222222222, 333333333333\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		value   string
+		stdin   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "comma-separated",
+			value: "111111111111,222222222222",
+			want:  []string{"111111111111", "222222222222"},
+		},
+		{
+			name:  "comma-separated with spaces",
+			value: "111111111111, 222222222222,",
+			want:  []string{"111111111111", "222222222222"},
+		},
+		{
+			name:  "file",
+			value: "@" + file,
+			want:  []string{"111111111111", "222222222222", "333333333333"},
+		},
+		{
+			name:  "stdin",
+			value: "-",
+			stdin: "111111111111 222222222222\n",
+			want:  []string{"111111111111", "222222222222"},
+		},
+		{
+			name:    "empty stdin",
+			value:   "-",
+			stdin:   "# nothing\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			value:   "@" + filepath.Join(t.TempDir(), "missing.txt"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readAccountIds(tt.value, strings.NewReader(tt.stdin))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readAccountIds() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readAccountIds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pickerOptions(t *testing.T) {
+	accnts := acos.Accounts{
+		"333333333333": acos.Account{Id: toPointer("333333333333"), Name: toPointer("sandbox"), Status: types.AccountStatusSuspended},
+		"111111111111": acos.Account{Id: toPointer("111111111111"), Name: toPointer("payments-prod"), Status: types.AccountStatusActive},
+		"222222222222": acos.Account{Id: toPointer("222222222222"), Name: toPointer("payments-dev")},
+	}
+	tests := []struct {
+		name   string
+		groups *accountGroups
+		want   []pickerOption
+	}{
+		{
+			name: "sorted by name",
+			want: []pickerOption{
+				{Label: "222222222222 - payments-dev", AccountIds: []string{"222222222222"}},
+				{Label: "111111111111 - payments-prod (ACTIVE)", AccountIds: []string{"111111111111"}},
+				{Label: "333333333333 - sandbox (SUSPENDED)", AccountIds: []string{"333333333333"}},
+			},
+		},
+		{
+			name: "grouped by tag",
+			groups: &accountGroups{
+				Label: "tag team",
+				Names: map[string]string{"111111111111": "payments", "222222222222": "payments"},
+			},
+			want: []pickerOption{
+				{Label: "[All in tag team: (none) (1 account(s))]", AccountIds: []string{"333333333333"}, Group: true},
+				{Label: "  333333333333 - sandbox (SUSPENDED)", AccountIds: []string{"333333333333"}},
+				{Label: "[All in tag team: payments (2 account(s))]", AccountIds: []string{"222222222222", "111111111111"}, Group: true},
+				{Label: "  222222222222 - payments-dev", AccountIds: []string{"222222222222"}},
+				{Label: "  111111111111 - payments-prod (ACTIVE)", AccountIds: []string{"111111111111"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickerOptions(accnts, tt.groups); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pickerOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateGroupBy(t *testing.T) {
+	for groupBy, wantErr := range map[string]bool{"": false, "ou": false, "tag:env": false, "tag:": true, "team": true} {
+		if err := validateGroupBy(groupBy); (err != nil) != wantErr {
+			t.Errorf("validateGroupBy(%q) error = %v, wantErr %v", groupBy, err, wantErr)
+		}
+	}
+}
+
+func Test_filterAccountsByTags(t *testing.T) {
+	accnts := acos.Accounts{
+		"111111111111": acos.Account{Id: toPointer("111111111111")},
+		"222222222222": acos.Account{Id: toPointer("222222222222")},
+		"333333333333": acos.Account{Id: toPointer("333333333333")},
+	}
+	tags := acos.AccountTags{
+		"111111111111": {"env": "prod", "team": "payments"},
+		"222222222222": {"env": "prod", "team": "search"},
+	}
+	want, err := parseAccountTags("env=prod, team=payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedAccountIds(filterAccountsByTags(accnts, want, tags)); !reflect.DeepEqual(got, []string{"111111111111"}) {
+		t.Errorf("filterAccountsByTags() = %v, want [111111111111]", got)
+	}
+	if _, err := parseAccountTags("env"); err == nil {
+		t.Errorf("parseAccountTags() should fail without '='")
+	}
+}
+
+func Test_getAccounts_accountIdsActiveOnly(t *testing.T) {
+	acos.UseDemo(acos.NewDemoSource(1, time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)))
+	ctx := context.Background()
+	all, err := acos.ListAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids, activeIds []string
+	for id, a := range all {
+		ids = append(ids, id)
+		if a.Status == types.AccountStatusActive {
+			activeIds = append(activeIds, id)
+		}
+	}
+	sort.Strings(activeIds)
+	if len(activeIds) == len(ids) {
+		t.Fatal("the demo organization should have a non-active account")
+	}
+
+	got, err := getAccounts(ctx, GetAccountsOption{AccountIds: ids, ActiveOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sortedAccountIds(got), activeIds) {
+		t.Errorf("getAccounts() = %v, want %v", sortedAccountIds(got), activeIds)
+	}
+	for id, a := range got {
+		if a.JoinedTimestamp == nil || a.Arn == nil {
+			t.Errorf("getAccounts() should keep the details of the account %s, got %+v", id, a)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+// maxBackfillMonths is the max number of the months of the Cost Explorer data, with the multi-year data enabled.
+// The data of 14 months including this month is available by default.
+const maxBackfillMonths = 38
+
+// backfillMonth represents a month to backfill.
+type backfillMonth struct {
+	Key string // e.g. "2023-06".
+	Opt acos.AcosGetCostsOption
+}
+
+// backfillMonths returns the closed months and this month to backfill, from the oldest.
+// The closed months are retrieved with the MONTHLY granularity, and this month with the DAILY granularity as of `now`.
+func backfillMonths(now time.Time, months int) []backfillMonth {
+	var result []backfillMonth
+	for i := months - 1; i > 0; i-- {
+		m := firstDayOfMonth(now, -i)
+		result = append(result, backfillMonth{Key: m.Format("2006-01"), Opt: acos.NewClosedMonthOption(m)})
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return append(result, backfillMonth{Key: today.Format("2006-01"), Opt: acos.NewGetCostsOption(today)})
+}
+
+// backfilledSnapshots returns the IDs of the snapshots which the backfill command saved for the accounts, by the month and the date.
+func backfilledSnapshots(store *snapshotStore, q *snapshotQuery) (map[string]string, error) {
+	ids, err := store.ids()
+	if err != nil {
+		return nil, err
+	}
+	saved := make(map[string]string)
+	for _, id := range ids {
+		snap, err := store.load(id)
+		if err != nil {
+			return nil, err
+		}
+		if snap.Query == nil || len(snap.Query.Month) == 0 || !snap.Query.sameAccounts(q) {
+			continue
+		}
+		saved[snap.Query.Month+"@"+snap.AsOf.Format("2006-01-02")] = id
+	}
+	return saved, nil
+}
+
+// backfill retrieves the costs of the months which are not saved yet, and saves them as snapshots.
+// It stops at the first error, and the months saved until then are skipped when running it again.
+func backfill(ctx context.Context, w io.Writer, store *snapshotStore, guard *spendGuard, accounts acos.Accounts, q snapshotQuery, months []backfillMonth) error {
+	saved, err := backfilledSnapshots(store, &q)
+	if err != nil {
+		return err
+	}
+	for _, m := range months {
+		opt := m.Opt
+		opt.AllLinkedAccounts = q.AllLinkedAccounts
+		asOf := opt.AsOf()
+		if id, ok := saved[m.Key+"@"+asOf.Format("2006-01-02")]; ok {
+			fmt.Fprintf(w, "%s: skipped, already saved as %s.\n", m.Key, id)
+			continue
+		}
+		if err := guard.check(acos.PlanGetCosts(accounts, opt)); err != nil {
+			return err
+		}
+		costs, err := acos.GetCosts(ctx, accounts, opt)
+		if _, rerr := guard.record(); rerr != nil {
+			fmt.Fprintf(w, "Failed to record the Cost Explorer API usage: %s\n", rerr.Error())
+		}
+		var partialErr *acos.PartialError
+		if errors.As(err, &partialErr) {
+			// Don't save the month with the missing accounts, so that it's retried.
+			printPartialError(w, partialErr)
+			return fmt.Errorf("error failed to retrieve the costs of %s for some accounts", m.Key)
+		} else if err != nil {
+			return err
+		}
+		costArray, _ := toRows(costs, nil, nil)
+		q := q
+		q.Month = m.Key
+		id, err := store.save(snapshot{Query: &q, AsOf: asOf, Costs: costArray, Daily: dailyCostsOf(costArray)})
+		if err != nil {
+			return err
+		}
+		total := costs.Total()
+		fmt.Fprintf(w, "%s: saved as %s, %d account(s), $%.2f.\n", m.Key, id, len(costArray), total.AmountLastMonth+total.AmountThisMonth)
+	}
+	return nil
+}
+
+// runBackfill runs the "backfill" command, which saves the costs of the past months as snapshots.
+func runBackfill(args []string) {
+	fs := flag.NewFlagSet("acos backfill", flag.ExitOnError)
+	var ouId, commaSeparatedAccountIds string
+	var months int
+	var allLinkedAccounts, force bool
+	var monthlySpendCap float64
+	var timeout time.Duration
+	fs.IntVar(&months, "months", 14, fmt.Sprintf("Optional - The number of the months to backfill including this month, up to %d. Cost Explorer keeps the data of 14 months by default.", maxBackfillMonths))
+	fs.StringVar(&ouId, "ou", "", "Optional - Same as the -ou flag of acos.")
+	fs.StringVar(&commaSeparatedAccountIds, "accountIds", "", "Optional - Same as the -accountIds flag of acos. All the accounts in the organization or the OU are used by default.")
+	fs.BoolVar(&allLinkedAccounts, "allLinkedAccounts", false, "Optional - Same as the -allLinkedAccounts flag of acos.")
+	fs.DurationVar(&timeout, "timeout", 0, "Optional - Give up the backfill after the duration in total, e.g. '10m'. The months saved until then are skipped when running it again. Zero means no timeout.")
+	fs.Float64Var(&monthlySpendCap, "monthlySpendCap", 0, "Optional - Same as the -monthlySpendCap flag of acos. The backfill stops before the month which would exceed it.")
+	fs.BoolVar(&force, "force", false, "Optional - Same as the -force flag of acos.")
+	clientOpts := addClientFlags(fs)
+	demo := addDemoFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of acos backfill:\n  acos backfill [flags]\n\nSaves the costs of each month as snapshots, from the oldest month. See 'acos history'.\nThe closed months are retrieved with the MONTHLY granularity, and this month with the DAILY granularity.\nThe months already saved are skipped, so that it resumes when it runs again after an interruption.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if months < 1 || months > maxBackfillMonths {
+		fmt.Fprintf(os.Stderr, "error the -months flag should be between 1 and %d.\n", maxBackfillMonths)
+		os.Exit(2)
+	}
+	if monthlySpendCap < 0 {
+		fmt.Fprintln(os.Stderr, "error the -monthlySpendCap flag should not be negative.")
+		os.Exit(2)
+	}
+	if allLinkedAccounts && (len(ouId) > 0 || len(commaSeparatedAccountIds) > 0) {
+		fmt.Fprintln(os.Stderr, "error the -allLinkedAccounts flag can't be used with the flags to choose accounts.")
+		os.Exit(2)
+	}
+	accountIds, err := readAccountIds(commaSeparatedAccountIds, os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	configureClients(clientOpts)
+	dir, err := stateDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	guard := &spendGuard{dir: dir, counter: acos.EnableRequestCounter(), cap: monthlySpendCap, force: force, now: time.Now}
+	if demo.Enabled {
+		demo.use()
+		guard.cap = 0
+	}
+
+	ctx, cancel := withTimeout(context.Background(), timeout, 0)
+	defer cancel()
+	accounts, err := getAccounts(ctx, GetAccountsOption{AccountIds: accountIds, OuId: ouId})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(3)
+	}
+	q := snapshotQuery{Profile: awsProfile(), OuId: ouId, AllLinkedAccounts: allLinkedAccounts}
+	if demo.Enabled {
+		q.Source = demo.source()
+	}
+	if !allLinkedAccounts {
+		q.AccountIds = sortedAccountIds(accounts)
+	}
+
+	err = backfill(ctx, os.Stderr, newSnapshotStore(dir), guard, accounts, q, backfillMonths(time.Now().UTC(), months))
+	if month, rerr := guard.record(); rerr == nil && !demo.Enabled {
+		printUsage(os.Stderr, guard.recorded, month, usageMonth(guard.now()))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		fmt.Fprintln(os.Stderr, "Run the same command again to resume the backfill.")
+		os.Exit(5)
+	}
+}
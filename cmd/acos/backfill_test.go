@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+func Test_backfillMonths(t *testing.T) {
+	now := time.Date(2023, 2, 18, 9, 30, 0, 0, time.UTC)
+	months := backfillMonths(now, 4)
+	var got []string
+	for _, m := range months {
+		got = append(got, m.Key+"@"+m.Opt.AsOf().Format("2006-01-02"))
+	}
+	want := []string{"2022-11@2022-12-01", "2022-12@2023-01-01", "2023-01@2023-02-01", "2023-02@2023-02-18"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("backfillMonths() = %v, want %v", got, want)
+	}
+	if got := backfillMonths(now, 1); len(got) != 1 || got[0].Key != "2023-02" {
+		t.Errorf("backfillMonths(1) = %v, want this month only", got)
+	}
+}
+
+func Test_backfill_resume(t *testing.T) {
+	store := newSnapshotStore(t.TempDir())
+	store.now = func() time.Time { return time.Date(2023, 2, 18, 9, 30, 0, 0, time.UTC) }
+	q := snapshotQuery{Profile: "default", AccountIds: []string{"111111111111"}}
+	months := backfillMonths(time.Date(2023, 2, 18, 0, 0, 0, 0, time.UTC), 3)
+
+	// All the months were saved by an interrupted backfill, and another query.
+	for _, m := range months {
+		saved := q
+		saved.Month = m.Key
+		if _, err := store.save(snapshot{Query: &saved, AsOf: m.Opt.AsOf(), Costs: []acos.Cost{{AccountID: "111111111111"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := snapshotQuery{Profile: "default", AccountIds: []string{"222222222222"}, Month: "2023-01"}
+	if _, err := store.save(snapshot{Query: &other, AsOf: months[1].Opt.AsOf()}); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := backfilledSnapshots(store, &q)
+	if err != nil {
+		t.Fatalf("backfilledSnapshots() error = %v", err)
+	}
+	want := map[string]string{
+		"2022-12@2023-01-01": "20230218T093000Z",
+		"2023-01@2023-02-01": "20230218T093000Z-2",
+		"2023-02@2023-02-18": "20230218T093000Z-3",
+	}
+	if !reflect.DeepEqual(saved, want) {
+		t.Errorf("backfilledSnapshots() = %v, want %v", saved, want)
+	}
+
+	// Nothing is retrieved, so that it doesn't call AWS.
+	var buf bytes.Buffer
+	guard := &spendGuard{dir: t.TempDir(), counter: new(fakeCounter), now: store.now}
+	if err := backfill(context.Background(), &buf, store, guard, acos.Accounts{}, q, months); err != nil {
+		t.Fatalf("backfill() error = %v", err)
+	}
+	if got := strings.Count(buf.String(), "skipped"); got != 3 {
+		t.Errorf("backfill() skipped %d months, want 3:\n%s", got, buf.String())
+	}
+}
+
+func Test_backfill_dailyCosts(t *testing.T) {
+	now := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	acos.UseDemo(acos.NewDemoSource(1, now))
+	ctx := context.Background()
+	accounts, err := getAccounts(ctx, GetAccountsOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newSnapshotStore(t.TempDir())
+	guard := &spendGuard{dir: t.TempDir(), counter: new(fakeCounter), now: func() time.Time { return now }}
+	q := snapshotQuery{Source: "demo:1", AccountIds: sortedAccountIds(accounts)}
+	if err := backfill(ctx, io.Discard, store, guard, accounts, q, backfillMonths(now, 2)); err != nil {
+		t.Fatalf("backfill() error = %v", err)
+	}
+
+	saved, err := backfilledSnapshots(store, &q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed, err := store.load(saved["2023-06@2023-07-01"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(closed.Daily) > 0 {
+		t.Errorf("the snapshot of the closed month has the daily costs of %d accounts, want none", len(closed.Daily))
+	}
+	current, err := store.load(saved["2023-07@2023-07-18"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(current.Daily) == 0 {
+		t.Fatal("the snapshot of this month has no daily costs")
+	}
+	for _, c := range current.Costs {
+		if c.AmountThisMonth > 0 && len(current.Daily[c.AccountID]) == 0 {
+			t.Errorf("the snapshot of this month has no daily costs of %s", c.AccountID)
+		}
+	}
+}
+
+func Test_snapshotStore_idsOrder(t *testing.T) {
+	store := newSnapshotStore(t.TempDir())
+	store.now = func() time.Time { return time.Date(2023, 7, 18, 9, 30, 0, 0, time.UTC) }
+	var want []string
+	for i := 0; i < 11; i++ {
+		id, err := store.save(snapshot{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, id)
+	}
+	if got, _ := store.ids(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ids() = %v, want %v", got, want)
+	}
+}
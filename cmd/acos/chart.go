@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/toricls/acos"
+)
+
+// charset represents the characters to draw sparklines and bar charts.
+type charset struct {
+	Sparks []rune // The sparkline characters from the lowest to the highest.
+	Bar    []rune // The bar characters from the narrowest to the full width of a character.
+}
+
+var (
+	unicodeCharset = charset{
+		Sparks: []rune("▁▂▃▄▅▆▇█"),
+		Bar:    []rune("▏▎▍▌▋▊▉█"),
+	}
+	asciiCharset = charset{
+		Sparks: []rune("_.,:-=+*#"),
+		Bar:    []rune("#"),
+	}
+)
+
+// chars represents the charset to use. It's switched to asciiCharset when the terminal doesn't support Unicode.
+var chars = unicodeCharset
+
+const barChartWidth = 40 // The width of the longest bar in characters.
+
+// supportsUnicode returns true if the locale environment variables indicate UTF-8.
+func supportsUnicode() bool {
+	// The first non-empty variable takes precedence, in the same way as the POSIX locale.
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(env); len(v) > 0 {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
+
+// sparkline returns the sparkline of the daily costs, scaled between the minimum and the maximum amounts.
+func sparkline(daily []acos.DailyCost) string {
+	if len(daily) == 0 {
+		return ""
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, d := range daily {
+		lo = math.Min(lo, d.Amount)
+		hi = math.Max(hi, d.Amount)
+	}
+	var b strings.Builder
+	for _, d := range daily {
+		level := 0
+		if hi > lo {
+			level = int((d.Amount - lo) / (hi - lo) * float64(len(chars.Sparks)-1))
+		}
+		b.WriteRune(chars.Sparks[level])
+	}
+	return b.String()
+}
+
+// bar returns the horizontal bar of the amount, scaled by the highest amount to the `width` characters.
+func bar(amount, highest float64, width int) string {
+	if highest <= 0 || amount <= 0 {
+		return ""
+	}
+	// Use the partial characters for the fraction if the charset has them.
+	steps := len(chars.Bar)
+	units := int(math.Round(amount / highest * float64(width*steps)))
+	full, rest := units/steps, units%steps
+	s := strings.Repeat(string(chars.Bar[steps-1]), full)
+	if rest > 0 {
+		s += string(chars.Bar[rest-1])
+	}
+	return s
+}
+
+// printBarChart prints the horizontal bar chart of this month's costs, ranked by the amount.
+func printBarChart(w io.Writer, costs []acos.Cost, nf numberFormat) {
+	ranked := make([]acos.Cost, len(costs))
+	copy(ranked, costs)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].AmountThisMonth > ranked[j].AmountThisMonth
+	})
+
+	labelWidth, highest := 0, 0.0
+	for _, c := range ranked {
+		if l := utf8.RuneCountInString(barChartLabel(c)); l > labelWidth {
+			labelWidth = l
+		}
+		highest = math.Max(highest, c.AmountThisMonth)
+	}
+	for _, c := range ranked {
+		b := bar(c.AmountThisMonth, highest, barChartWidth)
+		fmt.Fprintf(w, "%-*s | %s%s %s\n", labelWidth, barChartLabel(c), b, strings.Repeat(" ", barChartWidth-utf8.RuneCountInString(b)), nf.amount(c.AmountThisMonth))
+	}
+}
+
+func barChartLabel(c acos.Cost) string {
+	return fmt.Sprintf("%s - %s", c.AccountID, c.AccountName)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/toricls/acos"
+)
+
+func Test_sparkline(t *testing.T) {
+	daily := []acos.DailyCost{{Date: "2023-07-01", Amount: 0}, {Date: "2023-07-02", Amount: 3.5}, {Date: "2023-07-03", Amount: 7}, {Date: "2023-07-04", Amount: 1}}
+	tests := []struct {
+		name  string
+		chars charset
+		daily []acos.DailyCost
+		want  string
+	}{
+		{name: "unicode", chars: unicodeCharset, daily: daily, want: "▁▄█▂"},
+		{name: "ascii", chars: asciiCharset, daily: daily, want: "_-#."},
+		{name: "flat", chars: unicodeCharset, daily: []acos.DailyCost{{Date: "2023-07-01", Amount: 2}, {Date: "2023-07-02", Amount: 2}}, want: "▁▁"},
+		{name: "empty", chars: unicodeCharset, daily: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chars = tt.chars
+			defer func() { chars = unicodeCharset }()
+			if got := sparkline(tt.daily); got != tt.want {
+				t.Errorf("sparkline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bar(t *testing.T) {
+	tests := []struct {
+		name    string
+		chars   charset
+		amount  float64
+		highest float64
+		want    string
+	}{
+		{name: "full", chars: unicodeCharset, amount: 10, highest: 10, want: "████"},
+		{name: "partial", chars: unicodeCharset, amount: 5.5, highest: 10, want: "██▎"},
+		{name: "ascii", chars: asciiCharset, amount: 5.5, highest: 10, want: "##"},
+		{name: "zero", chars: unicodeCharset, amount: 0, highest: 10, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chars = tt.chars
+			defer func() { chars = unicodeCharset }()
+			if got := bar(tt.amount, tt.highest, 4); got != tt.want {
+				t.Errorf("bar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_printBarChart(t *testing.T) {
+	chars = asciiCharset
+	defer func() { chars = unicodeCharset }()
+	nf, _ := newNumberFormat("en-US", "$", 2, false)
+	var b bytes.Buffer
+	printBarChart(&b, []acos.Cost{
+		{AccountID: "123456789012", AccountName: "my-sandbox", AmountThisMonth: 10},
+		{AccountID: "567890123456", AccountName: "my-prod", AmountThisMonth: 40},
+	}, nf)
+	want := "567890123456 - my-prod    | ######################################## $40.00\n" +
+		"123456789012 - my-sandbox | ##########                               $10.00\n"
+	if got := b.String(); got != want {
+		t.Errorf("printBarChart() = \n%v, want \n%v", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/toricls/acos"
+)
+
+// row represents a row of the outputs, which is a Cost with additional account information.
+type row struct {
+	acos.Cost
+	Ou   acos.Ou
+	Tags map[string]string
+}
+
+// column represents a column of the outputs.
+type column struct {
+	Key    string // The column name used in the -columns flag, and in the CSV and JSON outputs.
+	Header string // The column name used in the table output.
+	Align  int
+	Total  bool // Show the total in the table footer.
+	// Raw returns the unformatted value for the CSV and JSON outputs. It should be either one of string, float64, *float64 or []float64.
+	Raw func(r row) any
+	// Cell returns the formatted value for the table output.
+	Cell func(r row, nf numberFormat) string
+}
+
+// columns represents all the available columns in the order of the help message.
+var columns = []column{
+	stringColumn("accountId", "Account ID", func(r row) string { return r.AccountID }),
+	{
+		Key:    "accountName",
+		Header: "Account Name",
+		Align:  tablewriter.ALIGN_LEFT,
+		Raw:    func(r row) any { return r.AccountName },
+		Cell:   func(r row, _ numberFormat) string { return r.AccountName + lifecycleMarkers(r) },
+	},
+	amountColumn("thisMonth", "This Month", func(r row) float64 { return r.AmountThisMonth }),
+	percentColumn("mtdChange", "MTD Change (%)", func(r row) *float64 { return r.MonthToDateChangePercent }),
+	signedAmountColumn("mtdDelta", "vs Last Month MTD", func(r row) float64 { return r.AmountThisMonth - r.AmountLastMonthSamePeriod }),
+	signedAmountColumn("yesterday", "vs Yesterday", func(r row) float64 { return r.LatestDailyCostIncrease }),
+	percentColumn("dailyChange", "Daily Change (%)", func(r row) *float64 { return r.DailyChangePercent }),
+	signedAmountColumn("lastWeek", "vs Last Week", func(r row) float64 { return r.LatestWeeklyCostIncrease }),
+	percentColumn("weeklyChange", "Weekly Change (%)", func(r row) *float64 { return r.WeeklyChangePercent }),
+	amountColumn("lastMonth", "Last Month", func(r row) float64 { return r.AmountLastMonth }),
+	optionalAmountColumn("forecast", "Forecast", func(r row) *float64 { return r.ForecastThisMonth }),
+	stringColumn("ou", "OU", func(r row) string { return r.Ou.Name }),
+	stringColumn("status", "Status", func(r row) string { return r.AccountStatus }),
+	stringColumn("joined", "Joined", func(r row) string {
+		if r.JoinedTimestamp == nil {
+			return ""
+		}
+		return r.JoinedTimestamp.UTC().Format("2006-01-02")
+	}),
+	{
+		Key:    "sparkline",
+		Header: "Daily Trend",
+		Align:  tablewriter.ALIGN_LEFT,
+		Raw: func(r row) any {
+			amounts := make([]float64, len(r.Daily))
+			for i, d := range r.Daily {
+				amounts[i] = d.Amount
+			}
+			return amounts
+		},
+		Cell: func(r row, _ numberFormat) string { return sparkline(r.Daily) },
+	},
+}
+
+// lifecycleMarkers returns the markers to append to the account name in the table output.
+// It marks the accounts which are not active but may still incur costs, and the accounts which joined
+// the organization this month, whose costs of the last month are expected to be partial.
+func lifecycleMarkers(r row) string {
+	var markers string
+	if len(r.AccountStatus) > 0 && r.AccountStatus != string(types.AccountStatusActive) {
+		markers += fmt.Sprintf(" [%s]", r.AccountStatus)
+	}
+	if r.JoinedThisMonth {
+		markers += " [NEW]"
+	}
+	return markers
+}
+
+// tagColumnPrefix is the prefix of the column keys to show account tags, e.g. "tag:owner".
+const tagColumnPrefix = "tag:"
+
+// tagColumn returns the column to show the value of the account tag.
+func tagColumn(tagKey string) column {
+	return stringColumn(tagColumnPrefix+tagKey, "Tag "+tagKey, func(r row) string { return r.Tags[tagKey] })
+}
+
+// defaultColumnKeys returns the column keys used when the -columns flag is not set.
+func defaultColumnKeys(comparedTo string) []string {
+	if comparedTo == "LAST_WEEK" {
+		return []string{"accountId", "accountName", "thisMonth", "mtdChange", "lastWeek", "weeklyChange", "lastMonth"}
+	}
+	return []string{"accountId", "accountName", "thisMonth", "mtdChange", "yesterday", "dailyChange", "lastMonth"}
+}
+
+// columnKeys returns the keys of all the available columns.
+func columnKeys() []string {
+	keys := make([]string, len(columns))
+	for i, c := range columns {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+// parseColumns returns the columns for the comma-separated column keys.
+func parseColumns(commaSeparatedKeys string) ([]column, error) {
+	var result []column
+	for _, key := range strings.Split(commaSeparatedKeys, ",") {
+		key = strings.TrimSpace(key)
+		if len(key) == 0 {
+			continue
+		}
+		if tagKey, ok := strings.CutPrefix(key, tagColumnPrefix); ok && len(tagKey) > 0 {
+			result = append(result, tagColumn(tagKey))
+			continue
+		}
+		c, ok := findColumn(key)
+		if !ok {
+			return nil, fmt.Errorf("error unknown column '%s'. It should be one of '%s', or '%s<key>'", key, strings.Join(columnKeys(), "', '"), tagColumnPrefix)
+		}
+		result = append(result, c)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("error no columns specified")
+	}
+	return result, nil
+}
+
+func findColumn(key string) (column, bool) {
+	for _, c := range columns {
+		if c.Key == key {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// tagColumnKeys returns the tag keys of the tag columns in the columns.
+func tagColumnKeys(cols []column) []string {
+	var keys []string
+	for _, c := range cols {
+		if tagKey, ok := strings.CutPrefix(c.Key, tagColumnPrefix); ok {
+			keys = append(keys, tagKey)
+		}
+	}
+	return keys
+}
+
+// hasColumn returns true if the columns contain the column of the given key.
+func hasColumn(cols []column, key string) bool {
+	for _, c := range cols {
+		if c.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func stringColumn(key, header string, value func(r row) string) column {
+	return column{
+		Key:    key,
+		Header: header,
+		Align:  tablewriter.ALIGN_LEFT,
+		Raw:    func(r row) any { return value(r) },
+		Cell:   func(r row, _ numberFormat) string { return value(r) },
+	}
+}
+
+func amountColumn(key, header string, value func(r row) float64) column {
+	return column{
+		Key:    key,
+		Header: header,
+		Align:  tablewriter.ALIGN_RIGHT,
+		Total:  true,
+		Raw:    func(r row) any { return value(r) },
+		Cell:   func(r row, nf numberFormat) string { return nf.amount(value(r)) },
+	}
+}
+
+func signedAmountColumn(key, header string, value func(r row) float64) column {
+	return column{
+		Key:    key,
+		Header: header,
+		Align:  tablewriter.ALIGN_RIGHT,
+		Total:  true,
+		Raw:    func(r row) any { return value(r) },
+		Cell:   func(r row, nf numberFormat) string { return nf.signedAmount(value(r)) },
+	}
+}
+
+func optionalAmountColumn(key, header string, value func(r row) *float64) column {
+	return column{
+		Key:    key,
+		Header: header,
+		Align:  tablewriter.ALIGN_RIGHT,
+		Total:  true,
+		Raw:    func(r row) any { return value(r) },
+		Cell: func(r row, nf numberFormat) string {
+			if v := value(r); v != nil {
+				return nf.amount(*v)
+			}
+			return "N/A"
+		},
+	}
+}
+
+func percentColumn(key, header string, value func(r row) *float64) column {
+	return column{
+		Key:    key,
+		Header: header,
+		Align:  tablewriter.ALIGN_RIGHT,
+		Total:  true,
+		Raw:    func(r row) any { return value(r) },
+		Cell:   func(r row, nf numberFormat) string { return nf.percent(value(r)) },
+	}
+}
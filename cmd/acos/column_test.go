@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/toricls/acos"
+)
+
+func Test_parseColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "valid", keys: "accountId, thisMonth,forecast", want: []string{"accountId", "thisMonth", "forecast"}},
+		{name: "tag columns", keys: "accountId,tag:owner,tag:cost-center", want: []string{"accountId", "tag:owner", "tag:cost-center"}},
+		{name: "unknown column", keys: "accountId,nope", wantErr: true},
+		{name: "tag column without key", keys: "tag:", wantErr: true},
+		{name: "empty", keys: " , ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseColumns(tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseColumns() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotKeys []string
+			for _, c := range got {
+				gotKeys = append(gotKeys, c.Key)
+			}
+			if !reflect.DeepEqual(gotKeys, tt.want) {
+				t.Errorf("parseColumns() = %v, want %v", gotKeys, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getFooterCells(t *testing.T) {
+	nf, _ := newNumberFormat("en-US", "$", 2, false)
+	total := row{Cost: acos.Cost{AmountThisMonth: 1234.5, LatestDailyCostIncrease: -1}}
+	tests := []struct {
+		name string
+		keys string
+		want []string
+	}{
+		{name: "label before the first total", keys: "accountId,accountName,thisMonth,yesterday,ou", want: []string{"", "Total", "$1,234.50", "- $1.00", ""}},
+		{name: "no label", keys: "thisMonth,accountId", want: []string{"$1,234.50", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, _ := parseColumns(tt.keys)
+			if got := getFooterCells(total, cols, nf); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getFooterCells() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_lifecycleMarkers(t *testing.T) {
+	tests := []struct {
+		name string
+		cost acos.Cost
+		want string
+	}{
+		{name: "active", cost: acos.Cost{AccountStatus: "ACTIVE"}, want: ""},
+		{name: "unknown status", cost: acos.Cost{}, want: ""},
+		{name: "suspended", cost: acos.Cost{AccountStatus: "SUSPENDED"}, want: " [SUSPENDED]"},
+		{name: "new and pending closure", cost: acos.Cost{AccountStatus: "PENDING_CLOSURE", JoinedThisMonth: true}, want: " [PENDING_CLOSURE] [NEW]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lifecycleMarkers(row{Cost: tt.cost}); got != tt.want {
+				t.Errorf("lifecycleMarkers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
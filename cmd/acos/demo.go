@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+// demoOptions represents the flags of the demo mode.
+type demoOptions struct {
+	Enabled bool
+	Seed    int64
+}
+
+// addDemoFlags adds the flags of the demo mode to the flag set.
+func addDemoFlags(fs *flag.FlagSet) *demoOptions {
+	o := &demoOptions{}
+	fs.BoolVar(&o.Enabled, "demo", false, "Optional - Use a synthetic organization with OUs, accounts and costs instead of AWS, e.g. to try acos or to take screenshots. Nothing is sent to AWS.")
+	fs.Int64Var(&o.Seed, "demoSeed", 1, "Optional - The seed to generate the synthetic organization of the -demo flag. The same seed generates the same organization.")
+	return o
+}
+
+// use replaces the AWS API clients with the synthetic organization.
+// It should be called after acos.Configure and acos.EnableRequestCounter.
+func (o *demoOptions) use() {
+	acos.UseDemo(acos.NewDemoSource(o.Seed, time.Now().UTC()))
+}
+
+// source returns the source of the snapshots saved in the demo mode, e.g. "demo:1".
+func (o *demoOptions) source() string {
+	return fmt.Sprintf("demo:%d", o.Seed)
+}
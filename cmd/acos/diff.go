@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/toricls/acos"
+)
+
+// diffMetric represents a metric of the costs to compare between snapshots.
+type diffMetric struct {
+	Key   string // Same as the column key.
+	Value func(c acos.Cost) *float64
+	// Period returns the period which the metric covers as of the date, e.g. "2023-06-01..2023-07-01".
+	// The changes of the metrics for the same period are restatements. Empty means it's never restated, e.g. forecasts.
+	Period func(asOf time.Time) string
+}
+
+func period(from, to time.Time) string {
+	return from.Format("2006-01-02") + ".." + to.Format("2006-01-02")
+}
+
+func firstDayOfMonth(t time.Time, months int) time.Time {
+	return time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+}
+
+var diffMetrics = []diffMetric{
+	{
+		Key:    "lastMonth",
+		Value:  func(c acos.Cost) *float64 { return &c.AmountLastMonth },
+		Period: func(asOf time.Time) string { return period(firstDayOfMonth(asOf, -1), firstDayOfMonth(asOf, 0)) },
+	},
+	{
+		Key:    "thisMonth",
+		Value:  func(c acos.Cost) *float64 { return &c.AmountThisMonth },
+		Period: func(asOf time.Time) string { return period(firstDayOfMonth(asOf, 0), asOf) },
+	},
+	{
+		Key:    "yesterday",
+		Value:  func(c acos.Cost) *float64 { return &c.LatestDailyCostIncrease },
+		Period: func(asOf time.Time) string { return period(asOf.AddDate(0, 0, -1), asOf) },
+	},
+	{
+		Key:    "lastWeek",
+		Value:  func(c acos.Cost) *float64 { return &c.LatestWeeklyCostIncrease },
+		Period: func(asOf time.Time) string { return period(asOf.AddDate(0, 0, -7), asOf) },
+	},
+	{
+		Key:    "forecast",
+		Value:  func(c acos.Cost) *float64 { return c.ForecastThisMonth },
+		Period: func(asOf time.Time) string { return "" },
+	},
+}
+
+// costChange represents a change of a metric of an account between snapshots.
+type costChange struct {
+	AccountID   string
+	AccountName string
+	Metric      string
+	A           *float64
+	B           *float64
+	Delta       float64
+	Restated    bool   `json:",omitempty"` // The metric covers the same period in both snapshots.
+	Period      string `json:",omitempty"` // The period of the restated metric.
+}
+
+// snapshotRef represents a snapshot in the diff.
+type snapshotRef struct {
+	ID   string `json:",omitempty"`
+	Path string `json:",omitempty"`
+	AsOf string
+}
+
+// costDiff represents the differences between two snapshots.
+type costDiff struct {
+	A, B        snapshotRef
+	Changes     []costChange
+	Appeared    []acos.Cost // The accounts only in B.
+	Disappeared []acos.Cost // The accounts only in A.
+}
+
+// diffSnapshots compares the costs of the accounts in the snapshots. Changes smaller than a cent are ignored.
+func diffSnapshots(a, b *snapshot) costDiff {
+	d := costDiff{
+		A: snapshotRef{ID: a.ID, AsOf: a.AsOf.Format("2006-01-02")},
+		B: snapshotRef{ID: b.ID, AsOf: b.AsOf.Format("2006-01-02")},
+	}
+	as, bs := costsByAccount(a.Costs), costsByAccount(b.Costs)
+	for _, id := range sortedKeys(as) {
+		ca := as[id]
+		cb, ok := bs[id]
+		if !ok {
+			d.Disappeared = append(d.Disappeared, ca)
+			continue
+		}
+		for _, m := range diffMetrics {
+			va, vb := m.Value(ca), m.Value(cb)
+			if va == nil && vb == nil {
+				continue
+			}
+			var delta float64
+			if va != nil && vb != nil {
+				delta = *vb - *va
+				if math.Abs(delta) < 0.005 {
+					continue
+				}
+			}
+			c := costChange{AccountID: id, AccountName: cb.AccountName, Metric: m.Key, A: va, B: vb, Delta: delta}
+			if p := m.Period(a.AsOf.UTC()); len(p) > 0 && p == m.Period(b.AsOf.UTC()) && va != nil && vb != nil {
+				c.Restated, c.Period = true, p
+			}
+			d.Changes = append(d.Changes, c)
+		}
+		d.Changes = append(d.Changes, diffDailyCosts(id, cb.AccountName, a.Daily[id], b.Daily[id])...)
+	}
+	for _, id := range sortedKeys(bs) {
+		if _, ok := as[id]; !ok {
+			d.Appeared = append(d.Appeared, bs[id])
+		}
+	}
+	return d
+}
+
+// diffDailyCosts returns the changes of the costs of the days in both snapshots, which are all restatements.
+func diffDailyCosts(accountId, accountName string, a, b []acos.DailyCost) []costChange {
+	amounts := make(map[string]float64, len(a))
+	for _, d := range a {
+		amounts[d.Date] = d.Amount
+	}
+	var changes []costChange
+	for _, d := range b {
+		va, ok := amounts[d.Date]
+		if !ok || math.Abs(d.Amount-va) < 0.005 {
+			continue
+		}
+		vb := d.Amount
+		day, _ := time.Parse("2006-01-02", d.Date)
+		changes = append(changes, costChange{
+			AccountID: accountId, AccountName: accountName, Metric: "daily", A: &va, B: &vb, Delta: vb - va,
+			Restated: true, Period: period(day, day.AddDate(0, 0, 1)),
+		})
+	}
+	return changes
+}
+
+func costsByAccount(costs []acos.Cost) map[string]acos.Cost {
+	m := make(map[string]acos.Cost, len(costs))
+	for _, c := range costs {
+		m[c.AccountID] = c
+	}
+	return m
+}
+
+func sortedKeys(m map[string]acos.Cost) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printDiffTable prints the changes, and the appeared and disappeared accounts in a table.
+func printDiffTable(w io.Writer, d costDiff, nf numberFormat) {
+	name := func(r snapshotRef) string {
+		if len(r.ID) > 0 {
+			return r.ID
+		}
+		return r.Path
+	}
+	fmt.Fprintf(w, "A: %s (as of %s)\nB: %s (as of %s)\n", name(d.A), d.A.AsOf, name(d.B), d.B.AsOf)
+	if len(d.Changes)+len(d.Appeared)+len(d.Disappeared) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+	amount := func(v *float64) string {
+		if v == nil {
+			return "-"
+		}
+		return nf.amount(*v)
+	}
+	t := tablewriter.NewWriter(w)
+	t.SetAutoWrapText(false)
+	t.SetHeader([]string{"Account ID", "Account Name", "Metric", "A", "B", "Delta", "Note"})
+	t.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+	for _, c := range d.Changes {
+		note := ""
+		if c.Restated {
+			note = "restated " + c.Period
+		}
+		delta := "-"
+		if c.A != nil && c.B != nil {
+			delta = nf.amount(c.Delta)
+		}
+		t.Append([]string{c.AccountID, c.AccountName, c.Metric, amount(c.A), amount(c.B), delta, note})
+	}
+	for _, c := range d.Disappeared {
+		t.Append([]string{c.AccountID, c.AccountName, "thisMonth", nf.amount(c.AmountThisMonth), "-", "-", "disappeared"})
+	}
+	for _, c := range d.Appeared {
+		t.Append([]string{c.AccountID, c.AccountName, "thisMonth", "-", nf.amount(c.AmountThisMonth), "-", "appeared"})
+	}
+	t.Render()
+}
+
+// loadDiffInput reads the snapshot of the file path, or the snapshot of the ID in the store.
+func loadDiffInput(store *snapshotStore, arg string) (*snapshot, snapshotRef, error) {
+	if _, err := os.Stat(arg); err == nil {
+		snap, err := readSnapshotFile(arg)
+		if err != nil {
+			return nil, snapshotRef{}, err
+		}
+		return snap, snapshotRef{ID: snap.ID, Path: arg}, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, snapshotRef{}, err
+	}
+	snap, err := store.load(arg)
+	if err != nil {
+		return nil, snapshotRef{}, err
+	}
+	return snap, snapshotRef{ID: snap.ID}, nil
+}
+
+// runDiff runs the "diff" command, which compares two snapshots or JSON outputs of acos.
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("acos diff", flag.ExitOnError)
+	var output string
+	fs.StringVar(&output, "output", "table", "Optional - The output format. It should be either 'table' or 'json'.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of acos diff:\n  acos diff [flags] <A> <B>\n\nCompares the costs of each account between A and B, which are snapshot IDs, 'latest', or files of the JSON output of acos without the -columns and -view flags.\nThe changes of the costs for the same period are marked as restated, including the daily costs in the snapshots.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if output != "table" && output != "json" {
+		fmt.Fprintln(os.Stderr, "error invalid value for the -output flag. It should be either 'table' or 'json'.")
+		os.Exit(2)
+	}
+
+	dir, err := stateDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(3)
+	}
+	store := newSnapshotStore(dir)
+	a, refA, err := loadDiffInput(store, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(3)
+	}
+	b, refB, err := loadDiffInput(store, fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(3)
+	}
+
+	d := diffSnapshots(a, b)
+	d.A.ID, d.A.Path = refA.ID, refA.Path
+	d.B.ID, d.B.Path = refB.ID, refB.Path
+	if output == "json" {
+		if err := json.NewEncoder(os.Stdout).Encode(d); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(6)
+		}
+		return
+	}
+	nf, _ := newNumberFormat("en-US", "$", 2, false)
+	printDiffTable(os.Stdout, d, nf)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+func Test_diffSnapshots(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	day := func(d int) time.Time { return time.Date(2023, 7, d, 0, 0, 0, 0, time.UTC) }
+	a := &snapshot{
+		ID:   "A",
+		AsOf: day(18),
+		Costs: []acos.Cost{
+			{AccountID: "111111111111", AccountName: "dev", AmountLastMonth: 100, AmountThisMonth: 50, LatestDailyCostIncrease: 3},
+			{AccountID: "222222222222", AccountName: "old", AmountThisMonth: 5},
+		},
+	}
+
+	t.Run("same month", func(t *testing.T) {
+		b := &snapshot{
+			ID:   "B",
+			AsOf: day(19),
+			Costs: []acos.Cost{
+				// Last month was restated, and this month-to-date grew.
+				{AccountID: "111111111111", AccountName: "dev", AmountLastMonth: 90, AmountThisMonth: 53, LatestDailyCostIncrease: 3, ForecastThisMonth: f(120)},
+				{AccountID: "333333333333", AccountName: "new", AmountThisMonth: 1},
+			},
+		}
+		d := diffSnapshots(a, b)
+		want := []costChange{
+			{AccountID: "111111111111", AccountName: "dev", Metric: "lastMonth", A: f(100), B: f(90), Delta: -10, Restated: true, Period: "2023-06-01..2023-07-01"},
+			{AccountID: "111111111111", AccountName: "dev", Metric: "thisMonth", A: f(50), B: f(53), Delta: 3},
+			{AccountID: "111111111111", AccountName: "dev", Metric: "forecast", B: f(120)},
+		}
+		if len(d.Changes) != len(want) {
+			t.Fatalf("Changes = %+v, want %+v", d.Changes, want)
+		}
+		for i, c := range d.Changes {
+			w := want[i]
+			if c.Metric != w.Metric || c.Delta != w.Delta || c.Restated != w.Restated || c.Period != w.Period || (c.A == nil) != (w.A == nil) || (c.B == nil) != (w.B == nil) {
+				t.Errorf("Changes[%d] = %+v, want %+v", i, c, w)
+			}
+		}
+		if len(d.Appeared) != 1 || d.Appeared[0].AccountID != "333333333333" {
+			t.Errorf("Appeared = %v, want 333333333333", d.Appeared)
+		}
+		if len(d.Disappeared) != 1 || d.Disappeared[0].AccountID != "222222222222" {
+			t.Errorf("Disappeared = %v, want 222222222222", d.Disappeared)
+		}
+
+		var buf bytes.Buffer
+		nf, _ := newNumberFormat("en-US", "$", 2, false)
+		printDiffTable(&buf, d, nf)
+		for _, s := range []string{"restated 2023-06-01..2023-07-01", "-$10.00", "appeared", "disappeared"} {
+			if !strings.Contains(buf.String(), s) {
+				t.Errorf("printDiffTable() doesn't contain %q:\n%s", s, buf.String())
+			}
+		}
+	})
+
+	t.Run("same date", func(t *testing.T) {
+		b := &snapshot{
+			AsOf: day(18),
+			Costs: []acos.Cost{
+				{AccountID: "111111111111", AccountName: "dev", AmountLastMonth: 100, AmountThisMonth: 51, LatestDailyCostIncrease: 3},
+				{AccountID: "222222222222", AccountName: "old", AmountThisMonth: 5},
+			},
+		}
+		d := diffSnapshots(a, b)
+		if len(d.Changes) != 1 || d.Changes[0].Metric != "thisMonth" || !d.Changes[0].Restated {
+			t.Errorf("Changes = %+v, want a restated thisMonth", d.Changes)
+		}
+	})
+
+	t.Run("daily costs", func(t *testing.T) {
+		costs := []acos.Cost{{AccountID: "111111111111", AccountName: "dev", Daily: []acos.DailyCost{{Date: "2023-07-16", Amount: 3}, {Date: "2023-07-17", Amount: 2}}}}
+		a := &snapshot{AsOf: day(18), Costs: costs, Daily: dailyCostsOf(costs)}
+		// The cost of 2023-07-17 was restated, and 2023-07-18 is only in B.
+		costs = []acos.Cost{{AccountID: "111111111111", AccountName: "dev", Daily: []acos.DailyCost{{Date: "2023-07-16", Amount: 3.001}, {Date: "2023-07-17", Amount: 2.5}, {Date: "2023-07-18", Amount: 4}}}}
+		b := &snapshot{AsOf: day(19), Costs: costs, Daily: dailyCostsOf(costs)}
+		d := diffSnapshots(a, b)
+		if len(d.Changes) != 1 {
+			t.Fatalf("Changes = %+v, want a restated daily cost", d.Changes)
+		}
+		if c := d.Changes[0]; c.Metric != "daily" || *c.A != 2 || *c.B != 2.5 || c.Delta != 0.5 || !c.Restated || c.Period != "2023-07-17..2023-07-18" {
+			t.Errorf("Changes[0] = %+v, want the restated cost of 2023-07-17", c)
+		}
+		if got := dailyCostsOf([]acos.Cost{{AccountID: "111111111111"}}); got != nil {
+			t.Errorf("dailyCostsOf() = %v, want nil without daily costs", got)
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		d := diffSnapshots(a, a)
+		if len(d.Changes)+len(d.Appeared)+len(d.Disappeared) > 0 {
+			t.Errorf("diffSnapshots() = %+v, want no changes", d)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/toricls/acos"
+)
+
+// runDoctor runs the "doctor" command, which checks the IAM permissions and the account settings required by acos.
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("acos doctor", flag.ExitOnError)
+	var ouId string
+	fs.StringVar(&ouId, "ou", "", "Optional - The ID of an AWS Organizational Unit (OU) or Root to check 'organizations:ListAccountsForParent' with.")
+	clientOpts := addClientFlags(fs)
+	demo := addDemoFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of acos doctor:\n  acos doctor [flags]\n\nChecks the IAM permissions and the account settings required by acos. It makes three Cost Explorer API requests, which cost $0.01 each.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	configureClients(clientOpts)
+	if demo.Enabled {
+		demo.use()
+	}
+
+	checks := acos.CheckPermissions(context.Background(), ouId)
+	if !printChecks(os.Stdout, checks) {
+		os.Exit(1)
+	}
+}
+
+// printChecks prints the results of the permission checks with how to fix the failures.
+// It returns false when any of the checks failed.
+func printChecks(w io.Writer, checks []acos.PermissionCheck) bool {
+	ok := true
+	for _, c := range checks {
+		switch {
+		case len(c.Skipped) > 0:
+			fmt.Fprintf(w, "[skip] %s (%s): %s\n", c.Action, c.Feature, c.Skipped)
+		case c.Err == nil:
+			fmt.Fprintf(w, "[ok]   %s (%s)\n", c.Action, c.Feature)
+		case errors.Is(c.Err, acos.ErrOrganizationNotInUse):
+			// Not a failure, acos falls back to the caller account.
+			fmt.Fprintf(w, "[warn] %s (%s)\n       %s\n", c.Action, c.Feature, explainError(c.Err))
+		default:
+			ok = false
+			fmt.Fprintf(w, "[fail] %s (%s)\n       %s\n", c.Action, c.Feature, explainError(c.Err))
+		}
+	}
+	if ok {
+		fmt.Fprintln(w, "\nAll the checks passed.")
+	} else {
+		fmt.Fprintln(w, "\nSome checks failed. See https://github.com/toricls/acos#prerequisites for the details.")
+	}
+	return ok
+}
+
+// explainError returns what to do to fix the error.
+func explainError(err error) string {
+	var accessDenied *acos.AccessDeniedError
+	var ouNotFound *acos.OuNotFoundError
+	switch {
+	case errors.Is(err, acos.ErrOrganizationNotInUse):
+		return "This AWS account is not part of AWS Organizations organization. acos only shows the costs of this account."
+	case errors.Is(err, acos.ErrCostExplorerNotEnabled):
+		return "Enable AWS Cost Explorer in the AWS Billing and Cost Management console. The data may take up to 24 hours to be ready."
+	case errors.Is(err, acos.ErrBillingAccessNotActivated):
+		return "Activate IAM access to the billing data with the root user in the account settings of the AWS Billing console."
+	case errors.As(err, &accessDenied):
+		return fmt.Sprintf("Allow \"%s\" in the IAM policy of your IAM user or role.", accessDenied.Action)
+	case errors.As(err, &ouNotFound):
+		return fmt.Sprintf("The OU \"%s\" doesn't exist. Check the -ou flag.", ouNotFound.OuId)
+	}
+	return err.Error()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/toricls/acos"
+)
+
+func Test_printChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   []acos.PermissionCheck
+		wantOk   bool
+		contains []string
+	}{
+		{
+			name: "all passed with a warning and a skip",
+			checks: []acos.PermissionCheck{
+				{Action: "sts:GetCallerIdentity", Feature: "the fallback outside organizations"},
+				{Action: "organizations:ListAccounts", Feature: "listing accounts", Err: fmt.Errorf("%w: boom", acos.ErrOrganizationNotInUse)},
+				{Action: "organizations:ListAccountsForParent", Feature: "the -ou flag", Skipped: "set the -ou flag to check it"},
+			},
+			wantOk:   true,
+			contains: []string{"[ok]   sts:GetCallerIdentity", "[warn] organizations:ListAccounts", "[skip] organizations:ListAccountsForParent", "All the checks passed."},
+		},
+		{
+			name: "failures",
+			checks: []acos.PermissionCheck{
+				{Action: "ce:GetCostAndUsage", Feature: "retrieving costs", Err: &acos.AccessDeniedError{Action: "ce:GetCostAndUsage", Err: errors.New("denied")}},
+				{Action: "ce:GetCostForecast", Feature: "the forecast column", Err: fmt.Errorf("%w: boom", acos.ErrCostExplorerNotEnabled)},
+			},
+			wantOk:   false,
+			contains: []string{"[fail] ce:GetCostAndUsage", "Allow \"ce:GetCostAndUsage\"", "Enable AWS Cost Explorer", "Some checks failed."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if got := printChecks(&b, tt.checks); got != tt.wantOk {
+				t.Errorf("printChecks() = %v, want %v", got, tt.wantOk)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(b.String(), s) {
+					t.Errorf("printChecks() output doesn't contain %q:\n%s", s, b.String())
+				}
+			}
+		})
+	}
+}
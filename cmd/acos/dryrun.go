@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/toricls/acos"
+)
+
+// dryRunAccounts returns the accounts of the IDs without calling AWS, so that their names are unknown.
+func dryRunAccounts(accountIds, excludeAccountIds []string) (acos.Accounts, error) {
+	accnts := make(acos.Accounts, len(accountIds))
+	for _, id := range accountIds {
+		accnts[id] = acos.Account{Id: aws.String(id), Name: aws.String("")}
+	}
+	return filterAccounts(accnts, GetAccountsOption{ExcludeAccountIds: excludeAccountIds})
+}
+
+// printPlan prints the inputs of the AWS API requests in the plan, and the estimated number of the requests and their price.
+func printPlan(w io.Writer, plan acos.CostsPlan) error {
+	for i, r := range plan {
+		fmt.Fprintf(w, "# %d/%d %s, estimated %d page(s)", i+1, len(plan), r.Action, r.Pages)
+		if len(r.Note) > 0 {
+			fmt.Fprintf(w, ", %s", r.Note)
+		}
+		fmt.Fprintln(w)
+		b, err := compactJson(r.Input)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", b)
+	}
+	fmt.Fprintf(w, "\nEstimated %d request(s), $%.2f. Each Cost Explorer API request costs $%.2f. Nothing was sent to AWS.\n",
+		plan.Requests(), plan.Price(), acos.CeRequestPrice)
+	return nil
+}
+
+// compactJson returns the indented JSON of the value without the null fields, which are never sent to AWS.
+func compactJson(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(dropNulls(doc), "", "  ")
+}
+
+// dropNulls removes the null fields from the decoded JSON recursively.
+func dropNulls(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			if e == nil {
+				delete(t, k)
+				continue
+			}
+			t[k] = dropNulls(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = dropNulls(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+func Test_printPlan(t *testing.T) {
+	accnts, err := dryRunAccounts([]string{"111111111111", "222222222222"}, []string{"222222222222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accnts) != 1 {
+		t.Fatalf("dryRunAccounts() = %d accounts, want 1", len(accnts))
+	}
+	plan := acos.PlanGetCosts(accnts, acos.NewGetCostsOption(time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)))
+
+	var buf bytes.Buffer
+	if err := printPlan(&buf, plan); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"# 1/1 ce:GetCostAndUsage, estimated 1 page(s)\n",
+		`"Values": [` + "\n" + `            "111111111111"` + "\n",
+		`"Start": "2023-06-01"`,
+		"Estimated 1 request(s), $0.01.",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("printPlan() doesn't contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "null") {
+		t.Errorf("printPlan() contains null fields:\n%s", got)
+	}
+}
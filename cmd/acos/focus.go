@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/toricls/acos"
+)
+
+// focusProviderName is the name of the provider, the publisher and the invoice issuer in the FOCUS output.
+const focusProviderName = "AWS"
+
+// focusColumns are the columns of the FinOps FOCUS specification 1.0 in the FOCUS output, in alphabetical order.
+// The columns which acos doesn't retrieve, such as the resources, the SKUs and the commitment discounts, are null.
+var focusColumns = []string{
+	"AvailabilityZone",
+	"BilledCost",
+	"BillingAccountId",
+	"BillingAccountName",
+	"BillingCurrency",
+	"BillingPeriodEnd",
+	"BillingPeriodStart",
+	"ChargeCategory",
+	"ChargeClass",
+	"ChargeDescription",
+	"ChargeFrequency",
+	"ChargePeriodEnd",
+	"ChargePeriodStart",
+	"CommitmentDiscountCategory",
+	"CommitmentDiscountId",
+	"CommitmentDiscountName",
+	"CommitmentDiscountStatus",
+	"CommitmentDiscountType",
+	"ConsumedQuantity",
+	"ConsumedUnit",
+	"ContractedCost",
+	"ContractedUnitPrice",
+	"EffectiveCost",
+	"InvoiceIssuerName",
+	"ListCost",
+	"ListUnitPrice",
+	"PricingCategory",
+	"PricingQuantity",
+	"PricingUnit",
+	"ProviderName",
+	"PublisherName",
+	"RegionId",
+	"RegionName",
+	"ResourceId",
+	"ResourceName",
+	"ResourceType",
+	"ServiceCategory",
+	"ServiceName",
+	"SkuId",
+	"SkuPriceId",
+	"SubAccountId",
+	"SubAccountName",
+	"Tags",
+}
+
+// focusDecimalColumns and focusDateTimeColumns are the FOCUS columns of the Decimal and the Date/Time types.
+// The other columns are String, except for Tags, which is always null.
+var (
+	focusDecimalColumns = map[string]bool{
+		"BilledCost": true, "ConsumedQuantity": true, "ContractedCost": true, "ContractedUnitPrice": true,
+		"EffectiveCost": true, "ListCost": true, "ListUnitPrice": true, "PricingQuantity": true,
+	}
+	focusDateTimeColumns = map[string]bool{
+		"BillingPeriodEnd": true, "BillingPeriodStart": true, "ChargePeriodEnd": true, "ChargePeriodStart": true,
+	}
+)
+
+// focusServiceCategories are the FOCUS service categories of the AWS services. The others are "Other".
+var focusServiceCategories = map[string]string{
+	"Amazon Elastic Compute Cloud - Compute":          "Compute",
+	"EC2 - Other":                                     "Compute",
+	"AWS Lambda":                                      "Compute",
+	"Amazon Elastic Container Service":                "Compute",
+	"Amazon Elastic Container Service for Kubernetes": "Compute",
+	"Amazon Lightsail":                                "Compute",
+	"Amazon Simple Storage Service":                   "Storage",
+	"Amazon Elastic File System":                      "Storage",
+	"AWS Backup":                                      "Storage",
+	"Amazon Relational Database Service":              "Databases",
+	"Amazon DynamoDB":                                 "Databases",
+	"Amazon ElastiCache":                              "Databases",
+	"Amazon Redshift":                                 "Analytics",
+	"Amazon Athena":                                   "Analytics",
+	"AWS Glue":                                        "Analytics",
+	"Amazon OpenSearch Service":                       "Analytics",
+	"Amazon Virtual Private Cloud":                    "Networking",
+	"Amazon CloudFront":                               "Networking",
+	"Amazon Route 53":                                 "Networking",
+	"Amazon Elastic Load Balancing":                   "Networking",
+	"AmazonCloudWatch":                                "Management and Governance",
+	"AWS CloudTrail":                                  "Management and Governance",
+	"AWS Config":                                      "Management and Governance",
+	"AWS Key Management Service":                      "Security",
+	"AWS Secrets Manager":                             "Security",
+	"Amazon GuardDuty":                                "Security",
+	"AWS Security Hub":                                "Security",
+	"AWS WAF":                                         "Security",
+	"Amazon Simple Queue Service":                     "Integration",
+	"Amazon Simple Notification Service":              "Integration",
+	"AWS Step Functions":                              "Integration",
+	"Amazon API Gateway":                              "Web",
+	"Amazon SageMaker":                                "AI and Machine Learning",
+	"Amazon Bedrock":                                  "AI and Machine Learning",
+}
+
+// isFocusOutput returns true when the -output flag value is one of the FOCUS outputs.
+func isFocusOutput(output string) bool {
+	return output == "focus" || output == "focus-parquet"
+}
+
+// focusRow represents a cost of a service of an account on a day in the FOCUS output.
+//
+// acos only retrieves the unblended costs, so that all of BilledCost, EffectiveCost, ListCost and ContractedCost
+// are the unblended cost. The costs are only of the usage charges, without the credits, the refunds and the others.
+// See acos.AcosGetCostsOption.UsageOnly. The usage types are summed up, so that the pricing and the consumed quantities are null.
+type focusRow struct {
+	BillingAccountId   string
+	BillingAccountName string
+	AccountID          string
+	AccountName        string
+	Service            string
+	Date               string // The format is "YYYY-MM-DD".
+	Amount             float64
+}
+
+// values returns the values of the FOCUS columns. The null values are empty.
+func (r focusRow) values() []string {
+	start, _ := time.Parse("2006-01-02", r.Date)
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	amount := strconv.FormatFloat(r.Amount, 'f', -1, 64)
+	category, ok := focusServiceCategories[r.Service]
+	if !ok {
+		category = "Other"
+	}
+	v := map[string]string{
+		"BilledCost":         amount,
+		"BillingAccountId":   r.BillingAccountId,
+		"BillingAccountName": r.BillingAccountName,
+		"BillingCurrency":    "USD",
+		"BillingPeriodEnd":   month.AddDate(0, 1, 0).Format(time.RFC3339),
+		"BillingPeriodStart": month.Format(time.RFC3339),
+		"ChargeCategory":     "Usage",
+		"ChargeDescription":  fmt.Sprintf("Daily unblended cost of %s", r.Service),
+		"ChargeFrequency":    "Usage-Based",
+		"ChargePeriodEnd":    start.AddDate(0, 0, 1).Format(time.RFC3339),
+		"ChargePeriodStart":  start.Format(time.RFC3339),
+		"ContractedCost":     amount,
+		"EffectiveCost":      amount,
+		"InvoiceIssuerName":  focusProviderName,
+		"ListCost":           amount,
+		"ProviderName":       focusProviderName,
+		"PublisherName":      focusProviderName,
+		"ServiceCategory":    category,
+		"ServiceName":        r.Service,
+		"SubAccountId":       r.AccountID,
+		"SubAccountName":     r.AccountName,
+	}
+	values := make([]string, len(focusColumns))
+	for i, c := range focusColumns {
+		values[i] = v[c]
+	}
+	return values
+}
+
+// focusBillingAccounts returns the billing accounts of the accounts in `costs` keyed by the account IDs, and the names
+// of the billing accounts keyed by their IDs. The billing account is the payer account in the data source,
+// or the caller account which retrieves the costs from Cost Explorer. The names are the ones in `accounts`, or in the
+// data source, and the alias of the caller account otherwise.
+func focusBillingAccounts(ctx context.Context, src *acos.LineItemSource, accounts acos.Accounts, costs []acos.Cost) (map[string]string, map[string]string, error) {
+	ids := make(map[string]string)
+	names := make(map[string]string)
+	if src != nil {
+		ids, accounts = src.PayerAccounts(), src.Accounts()
+	} else {
+		caller, err := acos.GetCallerAccount(ctx)
+		if len(caller[0]) == 0 {
+			return ids, names, err
+		}
+		if err == nil {
+			names[caller[0]] = caller[1]
+		}
+		for _, c := range costs {
+			ids[c.AccountID] = caller[0]
+		}
+	}
+	for _, id := range ids {
+		if a, ok := accounts[id]; ok && a.Name != nil {
+			names[id] = *a.Name
+		}
+	}
+	return ids, names, nil
+}
+
+// focusRows returns the daily costs of the services of the accounts, sorted by the dates, the account IDs and the services.
+// The days without costs are omitted. The billing accounts are keyed by the account IDs, and their names are keyed by
+// the billing account IDs. See focusBillingAccounts.
+func focusRows(costs []acos.Cost, services []acos.BreakdownItem, billingAccountIds, billingAccountNames map[string]string) []focusRow {
+	names := make(map[string]string, len(costs))
+	for _, c := range costs {
+		names[c.AccountID] = c.AccountName
+	}
+	var rows []focusRow
+	for _, s := range services {
+		for _, d := range s.Daily {
+			if d.Amount == 0 {
+				continue
+			}
+			billingAccountId := billingAccountIds[s.AccountID]
+			rows = append(rows, focusRow{
+				BillingAccountId:   billingAccountId,
+				BillingAccountName: billingAccountNames[billingAccountId],
+				AccountID:          s.AccountID,
+				AccountName:        names[s.AccountID],
+				Service:            s.Key,
+				Date:               d.Date,
+				Amount:             d.Amount,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Date != rows[j].Date {
+			return rows[i].Date < rows[j].Date
+		}
+		if rows[i].AccountID != rows[j].AccountID {
+			return rows[i].AccountID < rows[j].AccountID
+		}
+		return rows[i].Service < rows[j].Service
+	})
+	return rows
+}
+
+// printFocusCsv prints the rows in CSV with the FOCUS columns.
+func printFocusCsv(w io.Writer, rows []focusRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(focusColumns); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write(r.values()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// focusParquetSchema returns the Parquet schema of the FOCUS columns, which are all optional.
+// The Decimal columns are doubles, and the Date/Time columns are timestamps in milliseconds.
+func focusParquetSchema() *parquet.Schema {
+	g := make(parquet.Group, len(focusColumns))
+	for _, c := range focusColumns {
+		n := parquet.String()
+		if focusDecimalColumns[c] {
+			n = parquet.Leaf(parquet.DoubleType)
+		} else if focusDateTimeColumns[c] {
+			n = parquet.Timestamp(parquet.Millisecond)
+		}
+		g[c] = parquet.Optional(n)
+	}
+	return parquet.NewSchema("focus", g)
+}
+
+// printFocusParquet writes the rows in Parquet with the FOCUS columns.
+func printFocusParquet(w io.Writer, rows []focusRow) error {
+	pw := parquet.NewWriter(w, focusParquetSchema())
+	for _, r := range rows {
+		// The columns of a Parquet group are in alphabetical order, as focusColumns are.
+		row := make(parquet.Row, len(focusColumns))
+		for i, v := range r.values() {
+			c := focusColumns[i]
+			switch {
+			case len(v) == 0:
+				row[i] = parquet.NullValue().Level(0, 0, i)
+			case focusDecimalColumns[c]:
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					return err
+				}
+				row[i] = parquet.DoubleValue(f).Level(0, 1, i)
+			case focusDateTimeColumns[c]:
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					return err
+				}
+				row[i] = parquet.Int64Value(t.UnixMilli()).Level(0, 1, i)
+			default:
+				row[i] = parquet.ByteArrayValue([]byte(v)).Level(0, 1, i)
+			}
+		}
+		if _, err := pw.WriteRows([]parquet.Row{row}); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
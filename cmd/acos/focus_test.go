@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/parquet-go/parquet-go"
+	"github.com/toricls/acos"
+)
+
+func Test_focusRows(t *testing.T) {
+	costs := []acos.Cost{
+		{AccountID: "111111111111", AccountName: "dev"},
+		{AccountID: "222222222222", AccountName: "prod"},
+	}
+	services := []acos.BreakdownItem{
+		{Key: "Amazon EC2", Cost: acos.Cost{AccountID: "111111111111", Daily: []acos.DailyCost{{Date: "2023-06-30", Amount: 0.5}, {Date: "2023-07-01", Amount: 2}}}},
+		{Key: "Amazon S3", Cost: acos.Cost{AccountID: "111111111111", Daily: []acos.DailyCost{{Date: "2023-06-30", Amount: 0.5}, {Date: "2023-07-01", Amount: 0}}}},
+		{Key: "Amazon S3", Cost: acos.Cost{AccountID: "222222222222", Daily: []acos.DailyCost{{Date: "2023-06-30", Amount: 3}}}},
+	}
+	tests := []struct {
+		name                string
+		billingAccountIds   map[string]string
+		billingAccountNames map[string]string
+		want                []focusRow
+	}{
+		{
+			// The billing account is not one of the accounts in the costs.
+			name:                "billing account",
+			billingAccountIds:   map[string]string{"111111111111": "999999999999", "222222222222": "999999999999"},
+			billingAccountNames: map[string]string{"999999999999": "management"},
+			want: []focusRow{
+				{BillingAccountId: "999999999999", BillingAccountName: "management", AccountID: "111111111111", AccountName: "dev", Service: "Amazon EC2", Date: "2023-06-30", Amount: 0.5},
+				{BillingAccountId: "999999999999", BillingAccountName: "management", AccountID: "111111111111", AccountName: "dev", Service: "Amazon S3", Date: "2023-06-30", Amount: 0.5},
+				{BillingAccountId: "999999999999", BillingAccountName: "management", AccountID: "222222222222", AccountName: "prod", Service: "Amazon S3", Date: "2023-06-30", Amount: 3},
+				{BillingAccountId: "999999999999", BillingAccountName: "management", AccountID: "111111111111", AccountName: "dev", Service: "Amazon EC2", Date: "2023-07-01", Amount: 2},
+			},
+		},
+		{
+			name:              "billing accounts in the data source",
+			billingAccountIds: map[string]string{"111111111111": "888888888888"},
+			want: []focusRow{
+				{BillingAccountId: "888888888888", AccountID: "111111111111", AccountName: "dev", Service: "Amazon EC2", Date: "2023-06-30", Amount: 0.5},
+				{BillingAccountId: "888888888888", AccountID: "111111111111", AccountName: "dev", Service: "Amazon S3", Date: "2023-06-30", Amount: 0.5},
+				{AccountID: "222222222222", AccountName: "prod", Service: "Amazon S3", Date: "2023-06-30", Amount: 3},
+				{BillingAccountId: "888888888888", AccountID: "111111111111", AccountName: "dev", Service: "Amazon EC2", Date: "2023-07-01", Amount: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := focusRows(costs, services, tt.billingAccountIds, tt.billingAccountNames); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("focusRows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_focusBillingAccounts(t *testing.T) {
+	acos.UseDemo(acos.NewDemoSource(1, time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)))
+	ctx := context.Background()
+	caller, err := acos.GetCallerAccount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	costs := []acos.Cost{{AccountID: "111111111111", AccountName: "dev"}}
+
+	// The caller account isn't one of the selected accounts.
+	ids, names, err := focusBillingAccounts(ctx, nil, acos.Accounts{}, costs)
+	if err != nil {
+		t.Fatalf("focusBillingAccounts() error = %v", err)
+	}
+	if !reflect.DeepEqual(ids, map[string]string{"111111111111": caller[0]}) || !reflect.DeepEqual(names, map[string]string{caller[0]: caller[1]}) {
+		t.Errorf("focusBillingAccounts() = %v, %v, want the caller account %v", ids, names, caller)
+	}
+
+	// The name in the organization is preferred to the alias.
+	accounts := acos.Accounts{caller[0]: acos.Account{Id: &caller[0], Name: aws.String("management")}}
+	if _, names, _ := focusBillingAccounts(ctx, nil, accounts, costs); names[caller[0]] != "management" {
+		t.Errorf("focusBillingAccounts() names = %v, want the name in the organization", names)
+	}
+
+	// The payer accounts in the data source.
+	src := acos.NewLineItemSource([]acos.LineItem{
+		{AccountID: "111111111111", AccountName: "dev", PayerAccountID: "999999999999", Date: "2023-07-01", RecordType: "Usage", Service: "Amazon S3", Amount: 1},
+		{AccountID: "999999999999", AccountName: "payer", PayerAccountID: "999999999999", Date: "2023-07-01", RecordType: "Usage", Service: "Amazon S3", Amount: 1},
+	})
+	ids, names, err = focusBillingAccounts(ctx, src, nil, costs)
+	if err != nil || ids["111111111111"] != "999999999999" || names["999999999999"] != "payer" {
+		t.Errorf("focusBillingAccounts() = %v, %v, %v, want the payer account in the data source", ids, names, err)
+	}
+}
+
+// focusTestRow is the row of Test_printFocusCsv and Test_printFocusParquet, and focusTestValues are its values.
+var (
+	focusTestRow = focusRow{
+		BillingAccountId: "999999999999", AccountID: "111111111111", AccountName: "dev",
+		Service: "Amazon Elastic Compute Cloud - Compute", Date: "2023-06-30", Amount: 1.25,
+	}
+	focusTestValues = map[string]string{
+		"BilledCost":         "1.25",
+		"EffectiveCost":      "1.25",
+		"BillingAccountId":   "999999999999",
+		"BillingAccountName": "",
+		"BillingPeriodStart": "2023-06-01T00:00:00Z",
+		"BillingPeriodEnd":   "2023-07-01T00:00:00Z",
+		"ChargeClass":        "",
+		"ChargeDescription":  "Daily unblended cost of Amazon Elastic Compute Cloud - Compute",
+		"ChargePeriodStart":  "2023-06-30T00:00:00Z",
+		"ChargePeriodEnd":    "2023-07-01T00:00:00Z",
+		"PricingQuantity":    "",
+		"RegionId":           "",
+		"ServiceCategory":    "Compute",
+		"ServiceName":        "Amazon Elastic Compute Cloud - Compute",
+		"SubAccountId":       "111111111111",
+		"SubAccountName":     "dev",
+	}
+)
+
+func Test_printFocusCsv(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printFocusCsv(&buf, []focusRow{focusTestRow}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[0]) != len(focusColumns) {
+		t.Fatalf("printFocusCsv() = %d records of %d columns, want 2 records of %d columns", len(records), len(records[0]), len(focusColumns))
+	}
+	got := make(map[string]string)
+	for i, c := range records[0] {
+		got[c] = records[1][i]
+	}
+	for c, v := range focusTestValues {
+		if got[c] != v {
+			t.Errorf("printFocusCsv() %s = %q, want %q", c, got[c], v)
+		}
+	}
+}
+
+func Test_printFocusParquet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printFocusParquet(&buf, []focusRow{focusTestRow, focusTestRow}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.NumRows() != 2 {
+		t.Fatalf("printFocusParquet() = %d rows, want 2", f.NumRows())
+	}
+	rows := make([]parquet.Row, 1)
+	rr := f.RowGroups()[0].Rows()
+	defer rr.Close()
+	if n, _ := rr.ReadRows(rows); n != 1 {
+		t.Fatalf("printFocusParquet() read %d rows, want 1", n)
+	}
+	got := make(map[string]string)
+	for _, v := range rows[0] {
+		c := f.Schema().Columns()[v.Column()][0]
+		switch {
+		case v.IsNull():
+			got[c] = ""
+		case focusDateTimeColumns[c]:
+			got[c] = time.UnixMilli(v.Int64()).UTC().Format(time.RFC3339)
+		case focusDecimalColumns[c]:
+			got[c] = strconv.FormatFloat(v.Double(), 'f', -1, 64)
+		default:
+			got[c] = string(v.ByteArray())
+		}
+	}
+	if len(got) != len(focusColumns) {
+		t.Errorf("printFocusParquet() = %d columns, want %d", len(got), len(focusColumns))
+	}
+	for c, v := range focusTestValues {
+		if got[c] != v {
+			t.Errorf("printFocusParquet() %s = %q, want %q", c, got[c], v)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// numberFormat represents how amounts and percentages are formatted in the table output.
+// The JSON and CSV outputs never use it, to keep the values machine-readable.
+type numberFormat struct {
+	Precision     int    // The number of decimal places.
+	GroupSep      string // The thousands separator.
+	DecimalSep    string // The decimal separator.
+	Currency      string // The currency symbol, e.g. "$" and "€".
+	CurrencyAfter bool   // Place the currency symbol after the number, e.g. "1.234,56 €".
+	Compact       bool   // Abbreviate large numbers, e.g. "$12.3k".
+}
+
+// locales represents the supported locales and their separators.
+var locales = map[string]numberFormat{
+	"en-US": {GroupSep: ",", DecimalSep: "."},
+	"en-GB": {GroupSep: ",", DecimalSep: "."},
+	"ja-JP": {GroupSep: ",", DecimalSep: "."},
+	"de-DE": {GroupSep: ".", DecimalSep: ",", CurrencyAfter: true},
+	"es-ES": {GroupSep: ".", DecimalSep: ",", CurrencyAfter: true},
+	"it-IT": {GroupSep: ".", DecimalSep: ",", CurrencyAfter: true},
+	"nl-NL": {GroupSep: ".", DecimalSep: ","},
+	"pt-BR": {GroupSep: ".", DecimalSep: ","},
+	"fr-FR": {GroupSep: " ", DecimalSep: ",", CurrencyAfter: true},
+	"de-CH": {GroupSep: "'", DecimalSep: "."},
+}
+
+// compactUnits represents the suffixes for the compact format in descending order.
+var compactUnits = []struct {
+	threshold float64
+	suffix    string
+}{
+	{1e12, "T"},
+	{1e9, "B"},
+	{1e6, "M"},
+	{1e3, "k"},
+}
+
+// supportedLocales returns the sorted list of the supported locale names.
+func supportedLocales() []string {
+	names := make([]string, 0, len(locales))
+	for k := range locales {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newNumberFormat returns the numberFormat for the given locale. It returns an error for an unsupported locale.
+func newNumberFormat(locale, currency string, precision int, compact bool) (numberFormat, error) {
+	f, ok := locales[locale]
+	if !ok {
+		return numberFormat{}, fmt.Errorf("error unsupported locale '%s'. It should be one of '%s'", locale, strings.Join(supportedLocales(), "', '"))
+	}
+	if precision < 0 {
+		return numberFormat{}, fmt.Errorf("error invalid precision %d. It should be zero or a positive number", precision)
+	}
+	f.Precision = precision
+	f.Currency = currency
+	f.Compact = compact
+	return f, nil
+}
+
+// amount formats the amount with the currency symbol, e.g. "$1,234.56" and "-$1,234.56".
+func (f numberFormat) amount(v float64) string {
+	s := f.number(math.Abs(v))
+	if len(f.Currency) > 0 {
+		if f.CurrencyAfter {
+			s = s + " " + f.Currency
+		} else {
+			s = f.Currency + s
+		}
+	}
+	if v < 0 {
+		return "-" + s
+	}
+	return s
+}
+
+// signedAmount formats the amount with its sign separated by a space, e.g. "+ $1.50" and "- $1.50".
+func (f numberFormat) signedAmount(v float64) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s", getAmountPrefix(v), f.amount(math.Abs(v))))
+}
+
+// percent formats the percentage with its sign, e.g. "+ 12.34%". It returns "N/A" when `p` is nil.
+func (f numberFormat) percent(p *float64) string {
+	if p == nil {
+		return "N/A"
+	}
+	s := strings.Replace(strconv.FormatFloat(math.Abs(*p), 'f', 2, 64), ".", f.DecimalSep, 1)
+	return strings.TrimSpace(fmt.Sprintf("%s %s%%", getAmountPrefix(*p), s))
+}
+
+// number formats the non-negative number with the separators, or in the compact format.
+func (f numberFormat) number(v float64) string {
+	precision, suffix := f.Precision, ""
+	if f.Compact {
+		// The compact format always uses one decimal place, e.g. "12.3k" and "0.4".
+		precision = 1
+		// Move up a unit while the rounded value reaches 1000, e.g. 999,950 is "1.0M" rather than "1,000.0k".
+		scaled := v
+		for i := len(compactUnits) - 1; i >= 0 && math.Round(scaled*10)/10 >= 1000; i-- {
+			scaled, suffix = v/compactUnits[i].threshold, compactUnits[i].suffix
+		}
+		v = scaled
+	}
+	s := strconv.FormatFloat(v, 'f', precision, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(f.GroupSep)
+		}
+		b.WriteRune(r)
+	}
+	if len(fracPart) > 0 {
+		b.WriteString(f.DecimalSep)
+		b.WriteString(fracPart)
+	}
+	b.WriteString(suffix)
+	return b.String()
+}
+
+func getAmountPrefix(amount float64) string {
+	if amount > 0.0 {
+		return "+"
+	} else if amount < 0.0 {
+		return "-"
+	}
+	return ""
+}
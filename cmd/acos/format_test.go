@@ -0,0 +1,95 @@
+package main
+
+import "testing"
+
+func toFloatPointer(f float64) *float64 {
+	return &f
+}
+
+func mustNumberFormat(t *testing.T, locale, currency string, precision int, compact bool) numberFormat {
+	t.Helper()
+	f, err := newNumberFormat(locale, currency, precision, compact)
+	if err != nil {
+		t.Fatalf("newNumberFormat() error = %v", err)
+	}
+	return f
+}
+
+func Test_newNumberFormat(t *testing.T) {
+	if _, err := newNumberFormat("xx-XX", "$", 2, false); err == nil {
+		t.Errorf("newNumberFormat() error = nil, want error for an unsupported locale")
+	}
+	if _, err := newNumberFormat("en-US", "$", -1, false); err == nil {
+		t.Errorf("newNumberFormat() error = nil, want error for a negative precision")
+	}
+}
+
+func Test_numberFormat_amount(t *testing.T) {
+	tests := []struct {
+		name   string
+		f      numberFormat
+		amount float64
+		want   string
+	}{
+		{name: "en-US", f: mustNumberFormat(t, "en-US", "$", 2, false), amount: 5820.334869, want: "$5,820.33"},
+		{name: "en-US negative", f: mustNumberFormat(t, "en-US", "$", 2, false), amount: -1234567.891, want: "-$1,234,567.89"},
+		{name: "en-US no currency", f: mustNumberFormat(t, "en-US", "", 0, false), amount: 999.5, want: "1,000"},
+		{name: "de-DE", f: mustNumberFormat(t, "de-DE", "€", 2, false), amount: 1234.56, want: "1.234,56 €"},
+		{name: "fr-FR", f: mustNumberFormat(t, "fr-FR", "€", 1, false), amount: 1234567.25, want: "1 234 567,2 €"},
+		{name: "small", f: mustNumberFormat(t, "en-US", "$", 6, false), amount: 0.038331, want: "$0.038331"},
+		{name: "compact", f: mustNumberFormat(t, "en-US", "$", 2, true), amount: 12345.67, want: "$12.3k"},
+		{name: "compact million", f: mustNumberFormat(t, "de-DE", "€", 2, true), amount: 4560000, want: "4,6M €"},
+		{name: "compact small", f: mustNumberFormat(t, "en-US", "$", 2, true), amount: 0.38, want: "$0.4"},
+		{name: "compact rounded up to the next unit", f: mustNumberFormat(t, "en-US", "$", 2, true), amount: 999950, want: "$1.0M"},
+		{name: "compact below the rounding boundary", f: mustNumberFormat(t, "en-US", "$", 2, true), amount: 999949, want: "$999.9k"},
+		{name: "compact rounded up to thousand", f: mustNumberFormat(t, "en-US", "$", 2, true), amount: 999.96, want: "$1.0k"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.amount(tt.amount); got != tt.want {
+				t.Errorf("numberFormat.amount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_numberFormat_signedAmount(t *testing.T) {
+	f := mustNumberFormat(t, "en-US", "$", 2, false)
+	tests := []struct {
+		name   string
+		amount float64
+		want   string
+	}{
+		{name: "positive", amount: 12.3, want: "+ $12.30"},
+		{name: "negative", amount: -12.3, want: "- $12.30"},
+		{name: "zero", amount: 0, want: "$0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.signedAmount(tt.amount); got != tt.want {
+				t.Errorf("numberFormat.signedAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_numberFormat_percent(t *testing.T) {
+	tests := []struct {
+		name string
+		f    numberFormat
+		p    *float64
+		want string
+	}{
+		{name: "positive", f: mustNumberFormat(t, "en-US", "$", 2, false), p: toFloatPointer(6.25), want: "+ 6.25%"},
+		{name: "negative", f: mustNumberFormat(t, "en-US", "$", 2, false), p: toFloatPointer(-50), want: "- 50.00%"},
+		{name: "de-DE", f: mustNumberFormat(t, "de-DE", "€", 2, false), p: toFloatPointer(6.25), want: "+ 6,25%"},
+		{name: "nil", f: mustNumberFormat(t, "en-US", "$", 2, false), p: nil, want: "N/A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.percent(tt.p); got != tt.want {
+				t.Errorf("numberFormat.percent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
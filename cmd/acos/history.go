@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/toricls/acos"
+)
+
+// snapshotSummary represents a snapshot in the list of the snapshots.
+type snapshotSummary struct {
+	ID              string
+	AsOf            string
+	Accounts        int
+	AmountThisMonth float64 // The cost of the month as of the date, or the cost of the whole month for the backfilled snapshots.
+	Query           string  `json:",omitempty"`
+}
+
+func summarizeSnapshot(snap *snapshot) snapshotSummary {
+	costs := make(acos.Costs, len(snap.Costs))
+	for _, c := range snap.Costs {
+		costs[c.AccountID] = c
+	}
+	total := costs.Total()
+	amount := total.AmountThisMonth
+	if snap.Query != nil && len(snap.Query.Month) > 0 {
+		// The backfilled snapshots have the cost of the closed month as the last month's. See acos.NewClosedMonthOption.
+		amount = total.AmountLastMonth
+	}
+	return snapshotSummary{
+		ID:              snap.ID,
+		AsOf:            snap.AsOf.Format("2006-01-02"),
+		Accounts:        len(snap.Costs),
+		AmountThisMonth: amount,
+		Query:           snap.Query.String(),
+	}
+}
+
+// printSnapshotList prints the summaries of the snapshots in a table.
+func printSnapshotList(w io.Writer, summaries []snapshotSummary, nf numberFormat) {
+	t := tablewriter.NewWriter(w)
+	t.SetHeader([]string{"ID", "As of", "Accounts", "This Month", "Query"})
+	t.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_LEFT})
+	for _, s := range summaries {
+		t.Append([]string{s.ID, s.AsOf, fmt.Sprint(s.Accounts), nf.amount(s.AmountThisMonth), s.Query})
+	}
+	t.Render()
+}
+
+// runHistory runs the "history" command, which browses the snapshots saved by the -save flag.
+func runHistory(args []string) {
+	fs := flag.NewFlagSet("acos history", flag.ExitOnError)
+	var output string
+	fs.StringVar(&output, "output", "table", "Optional - The output format. It should be either 'table' or 'json'.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of acos history:\n  acos history list [flags]\n  acos history show [flags] <snapshot ID|latest>\n\nBrowses the snapshots saved by the -save flag.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || (args[0] != "list" && args[0] != "show") {
+		fs.Usage()
+		os.Exit(2)
+	}
+	cmd := args[0]
+	_ = fs.Parse(args[1:])
+	if output != "table" && output != "json" {
+		fmt.Fprintln(os.Stderr, "error invalid value for the -output flag. It should be either 'table' or 'json'.")
+		os.Exit(2)
+	}
+
+	dir, err := stateDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(3)
+	}
+	store := newSnapshotStore(dir)
+	nf, _ := newNumberFormat("en-US", "$", 2, false)
+
+	if cmd == "list" {
+		ids, err := store.ids()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(3)
+		}
+		summaries := make([]snapshotSummary, 0, len(ids))
+		for _, id := range ids {
+			snap, err := store.load(id)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(3)
+			}
+			summaries = append(summaries, summarizeSnapshot(snap))
+		}
+		if output == "json" {
+			err = json.NewEncoder(os.Stdout).Encode(summaries)
+		} else {
+			printSnapshotList(os.Stdout, summaries, nf)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(6)
+		}
+		return
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "error the show command requires a snapshot ID, or 'latest'.")
+		os.Exit(2)
+	}
+	snap, err := store.load(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(3)
+	}
+	if output == "json" {
+		if err := json.NewEncoder(os.Stdout).Encode(snap); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(6)
+		}
+		return
+	}
+	cols, _ := parseColumns(strings.Join(defaultColumnKeys("YESTERDAY"), ","))
+	rows := make([]row, len(snap.Costs))
+	for i, c := range snap.Costs {
+		rows[i] = row{Cost: c}
+	}
+	fmt.Fprintf(os.Stdout, "Snapshot %s: %s\n", snap.ID, snap.Query.String())
+	printTable(os.Stdout, rows, cols, snap.AsOf, nf, nil)
+}
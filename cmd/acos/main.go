@@ -2,40 +2,90 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/olekukonko/tablewriter"
+	"golang.org/x/term"
 
 	"github.com/toricls/acos"
 )
 
+// subcommands represents the commands other than the default one, which shows the costs of the selected accounts.
+var subcommands = map[string]func(args []string){
+	"tui":        runTui,
+	"doctor":     runDoctor,
+	"iam-policy": runIamPolicy,
+	"history":    runHistory,
+	"diff":       runDiff,
+	"backfill":   runBackfill,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
+		}
+	}
+
 	// Flags
-	var ouId, asOfStr, comparedTo, commaSeparatedAccountIds string
-	var useJson bool
+	var ouId, asOfStr, comparedTo, commaSeparatedAccountIds, output, accountNamePattern, excludeAccountNamePattern, commaSeparatedExcludeAccountIds, selectionName, groupBy, accountTags, locale, currency, commaSeparatedColumns, view, source string
+	var useJson, compact, ascii, editSelection, activeOnly, allLinkedAccounts, dryRun, force, save, byService bool
+	var precision, maxAttempts int
+	var rateLimit, monthlySpendCap float64
+	var watch, cacheTTL, maxBackoff, timeout time.Duration
 	flag.StringVar(&ouId, "ou", "", "Optional - The ID of an AWS Organizational Unit (OU) or Root to list direct-children AWS accounts. It must start with 'ou-' or 'r-' prefix. This flag is ignored when the -accountIds flag is set.")
 	flag.StringVar(&asOfStr, "asOf", "", "Optional - The date to retrieve the cost data. The format should be 'YYYY-MM-DD'. The default value is today in UTC.")
-	flag.StringVar(&comparedTo, "comparedTo", "YESTERDAY", "Optional - The cost of this month will be compared to either one of 'YESTERDAY' or 'LAST_WEEK'. This flag is ignored when the -json flag is set.")
-	flag.BoolVar(&useJson, "json", false, "Optional - Print JSON instead of table.")
-	flag.StringVar(&commaSeparatedAccountIds, "accountIds", "", "Optional - Comma-separated AWS account IDs to retrieve costs. The interactive account selector is skipped when this flag is set.")
+	flag.StringVar(&comparedTo, "comparedTo", "YESTERDAY", "Optional - The cost of this month will be compared to either one of 'YESTERDAY' or 'LAST_WEEK'. This flag is ignored when the -columns flag is set, or when the -output flag is 'json'.")
+	flag.StringVar(&commaSeparatedColumns, "columns", "", fmt.Sprintf("Optional - Comma-separated columns to show. Available columns are '%s', and 'tag:<key>' to show an account tag. The 'forecast' column calls the Cost Explorer API for each account. The JSON output only contains these columns when this flag is set.", strings.Join(columnKeys(), "', '")))
+	flag.BoolVar(&useJson, "json", false, "Optional - Print JSON instead of table. Same as '-output json'.")
+	flag.StringVar(&output, "output", "table", "Optional - The output format. It should be one of 'table', 'json', 'csv', 'focus' or 'focus-parquet'. The 'focus' and 'focus-parquet' outputs are CSV and Parquet in the FinOps FOCUS specification, with the daily costs of each service of each account from the first day of the last month, and they require the -byService flag. The JSON and CSV outputs are never formatted by the -precision, -locale, -currency and -compact flags.")
+	flag.BoolVar(&byService, "byService", false, "Optional - Break down the costs by AWS services, which the 'focus' and 'focus-parquet' outputs require. It retrieves the costs again grouped by the services, which takes more Cost Explorer API requests.")
+	flag.StringVar(&view, "view", "summary", "Optional - The view of the costs. It should be one of 'summary', 'daily', 'weekly' or 'bars'. The 'daily' and 'weekly' views show a matrix of accounts by days or ISO weeks, from the first day of the last month. The 'bars' view shows a bar chart of this month's costs, and it only supports the table output. The -columns and -comparedTo flags are ignored with these views.")
+	flag.BoolVar(&ascii, "ascii", false, "Optional - Draw sparklines and bar charts with ASCII characters. It's enabled automatically when the terminal doesn't seem to support Unicode.")
+	flag.IntVar(&precision, "precision", 2, "Optional - The number of decimal places of amounts in the table output.")
+	flag.StringVar(&locale, "locale", "en-US", fmt.Sprintf("Optional - The locale to format amounts in the table output. It should be one of '%s'.", strings.Join(supportedLocales(), "', '")))
+	flag.StringVar(&currency, "currency", "$", "Optional - The currency symbol of amounts in the table output. Set an empty string to omit it.")
+	flag.BoolVar(&compact, "compact", false, "Optional - Abbreviate large amounts in the table output, e.g. '$12.3k'.")
+	flag.DurationVar(&watch, "watch", 0, "Optional - Re-run the query every interval, e.g. '5m', and redraw the table in place with the changed cells highlighted. It only supports the table output of the summary view. The cost data is cached for an hour unless the -cacheTTL flag is set.")
+	flag.DurationVar(&cacheTTL, "cacheTTL", 0, "Optional - Cache the Cost Explorer API responses for the duration, e.g. '1h', to avoid paying for the same requests repeatedly.")
+	flag.StringVar(&commaSeparatedAccountIds, "accountIds", "", "Optional - Comma-separated AWS account IDs to retrieve costs. Use '@path' to read them from a file, or '-' to read them from stdin. The interactive account selector is skipped when this flag is set.")
+	flag.StringVar(&accountNamePattern, "accountName", "", "Optional - Only show the accounts whose name matches the pattern. The pattern is a glob such as 'myproduct-*', or a regular expression enclosed in slashes such as '/^myproduct-(dev|prod)$/'.")
+	flag.StringVar(&selectionName, "selection", "", "Optional - Name of the account selection to use. The accounts you select are saved under the name at the first time, and the saved accounts are used without the prompt afterwards.")
+	flag.StringVar(&groupBy, "groupBy", "", "Optional - Group the accounts in the interactive account selector by 'ou' or 'tag:<key>', with the options to select all the accounts in a group.")
+	flag.BoolVar(&editSelection, "editSelection", false, "Optional - Prompt to change the accounts of the selection specified by the -selection flag.")
+	flag.StringVar(&accountTags, "accountTag", "", "Optional - Only show the accounts which have the account tags, e.g. 'env=prod' or 'env=prod,team=payments'.")
+	flag.BoolVar(&activeOnly, "activeOnly", false, "Optional - Exclude the accounts which are not ACTIVE, e.g. SUSPENDED, from the account selector and the totals.")
+	flag.BoolVar(&allLinkedAccounts, "allLinkedAccounts", false, "Optional - Show the costs of all the linked accounts in the Cost Explorer data without the account selector, including the accounts which are not in the organization anymore. It can't be used with the flags to choose accounts.")
+	flag.StringVar(&commaSeparatedExcludeAccountIds, "excludeAccountIds", "", "Optional - Comma-separated AWS account IDs to exclude.")
+	flag.StringVar(&excludeAccountNamePattern, "excludeAccountName", "", "Optional - Exclude the accounts whose name matches the pattern. The pattern format is the same as the -accountName flag.")
+	flag.IntVar(&maxAttempts, "maxAttempts", 10, "Optional - The max number of attempts of an AWS API call. The throttled and the transient errors are retried with exponential backoff and jitter.")
+	flag.DurationVar(&maxBackoff, "maxBackoff", 20*time.Second, "Optional - The max wait time between the attempts of an AWS API call.")
+	flag.Float64Var(&rateLimit, "rateLimit", 0, "Optional - The max number of AWS API calls per second, including the retries. It helps to avoid the throttling when running acos for several organizations at once. Zero means no limit.")
+	flag.DurationVar(&timeout, "timeout", 0, "Optional - Give up retrieving the accounts and the costs after the duration in total, e.g. '2m'. The time in the interactive account selector doesn't count, and each refresh of the -watch flag has its own timeout. Zero means no timeout.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Optional - Print the Cost Explorer API requests which would be sent, and their estimated number and price, without calling AWS. It requires the -accountIds flag or the -allLinkedAccounts flag, since choosing the accounts otherwise calls AWS.")
+	flag.Float64Var(&monthlySpendCap, "monthlySpendCap", 0, "Optional - Refuse to run when the Cost Explorer API spend of this month in USD would exceed the amount, including the estimated requests of this run. The billable requests of every run are recorded in the state directory. Zero means no cap.")
+	flag.BoolVar(&force, "force", false, "Optional - Run even when the -monthlySpendCap flag would be exceeded.")
+	flag.BoolVar(&save, "save", false, "Optional - Save the query, the date and the costs of each account as a snapshot in the state directory. See 'acos history'. It can't be used with the -watch flag.")
+	flag.StringVar(&source, "source", "", "Optional - Read the costs from 'cur:<path>', the Cost and Usage Report (CUR 2.0 or legacy) CSV or Parquet files in the path, instead of the Cost Explorer API. The accounts are the ones in the files. It can't be used with the flags and the columns which require AWS Organizations.")
+	demo := addDemoFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of acos:\n  acos [flags]\n  acos tui [flags]\n  acos doctor [flags]\n  acos iam-policy [flags]\n  acos history list|show [flags]\n  acos diff [flags] <A> <B>\n  acos backfill [flags]\n\nFlags:\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	var asOf time.Time
-	if len(asOfStr) > 0 {
-		if t, err := time.Parse("2006-01-02", asOfStr); err != nil {
-			fmt.Fprintln(os.Stderr, "error invalid date format for the --asOf flag. It should be 'YYYY-MM-DD'.")
-			os.Exit(1)
-		} else {
-			asOf = t
-		}
-	} else {
-		asOf = time.Now().UTC()
+	asOf, err := parseAsOf(asOfStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
 	}
 
 	switch comparedTo {
@@ -47,41 +97,392 @@ func main() {
 		os.Exit(2)
 	}
 
-	var accountIds []string
-	if len(commaSeparatedAccountIds) > 0 {
-		accountIds = strings.Split(commaSeparatedAccountIds, ",")
+	if useJson {
+		output = "json"
+	}
+	switch output {
+	case "table":
+	case "json":
+	case "csv":
+	case "focus":
+	case "focus-parquet":
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			fmt.Fprintln(os.Stderr, "error the 'focus-parquet' output is binary. Redirect it to a file.")
+			os.Exit(2)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "error invalid value for the -output flag. It should be one of 'table', 'json', 'csv', 'focus' or 'focus-parquet'.")
+		os.Exit(2)
+	}
+	if isFocusOutput(output) && view != "summary" {
+		fmt.Fprintln(os.Stderr, "error the 'focus' and 'focus-parquet' outputs only support the summary view.")
+		os.Exit(2)
+	}
+	if byService != isFocusOutput(output) {
+		// The ServiceName column of FOCUS must not be null.
+		fmt.Fprintln(os.Stderr, "error the -byService flag is required by, and only available with, the 'focus' and 'focus-parquet' outputs.")
+		os.Exit(2)
+	}
+	switch view {
+	case "summary":
+	case "daily":
+	case "weekly":
+		break
+	case "bars":
+		if output != "table" {
+			fmt.Fprintln(os.Stderr, "error the 'bars' view only supports the table output.")
+			os.Exit(2)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "error invalid value for the -view flag. It should be one of 'summary', 'daily', 'weekly' or 'bars'.")
+		os.Exit(2)
+	}
+	if watch > 0 && (output != "table" || view != "summary") {
+		fmt.Fprintln(os.Stderr, "error the -watch flag only supports the table output of the summary view.")
+		os.Exit(2)
+	}
+	if save && watch > 0 {
+		fmt.Fprintln(os.Stderr, "error the -save flag can't be used with the -watch flag.")
+		os.Exit(2)
+	}
+	if ascii || !supportsUnicode() {
+		chars = asciiCharset
+	}
+	nf, err := newNumberFormat(locale, currency, precision, compact)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	cols, err := parseColumns(strings.Join(defaultColumnKeys(comparedTo), ","))
+	if len(commaSeparatedColumns) > 0 {
+		cols, err = parseColumns(commaSeparatedColumns)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	if err := validateGroupBy(groupBy); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	if allLinkedAccounts && (len(ouId) > 0 || len(commaSeparatedAccountIds) > 0 || len(accountNamePattern) > 0 || len(commaSeparatedExcludeAccountIds) > 0 ||
+		len(excludeAccountNamePattern) > 0 || len(accountTags) > 0 || activeOnly || len(selectionName) > 0) {
+		fmt.Fprintln(os.Stderr, "error the -allLinkedAccounts flag can't be used with the flags to choose accounts.")
+		os.Exit(2)
+	}
+	if len(source) > 0 && demo.Enabled {
+		fmt.Fprintln(os.Stderr, "error the -source flag can't be used with the -demo flag.")
+		os.Exit(2)
+	}
+	if len(source) > 0 && (len(ouId) > 0 || len(accountTags) > 0 || len(groupBy) > 0 || activeOnly || dryRun || hasColumn(cols, "ou") || len(tagColumnKeys(cols)) > 0) {
+		fmt.Fprintln(os.Stderr, "error the -source flag can't be used with the -ou, -accountTag, -groupBy, -activeOnly and -dry-run flags, and the ou and tag columns.")
+		os.Exit(2)
+	}
+	tagFilter, err := parseAccountTags(accountTags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	accountIds, err := readAccountIds(commaSeparatedAccountIds, os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+
+	if dryRun {
+		if len(accountIds) == 0 && !allLinkedAccounts {
+			fmt.Fprintln(os.Stderr, "error the -dry-run flag requires the -accountIds flag or the -allLinkedAccounts flag.")
+			os.Exit(2)
+		}
+		if len(accountNamePattern) > 0 || len(excludeAccountNamePattern) > 0 || len(accountTags) > 0 || activeOnly {
+			fmt.Fprintln(os.Stderr, "error the -dry-run flag can't be used with the flags to filter accounts by their names, tags or status, which call AWS.")
+			os.Exit(2)
+		}
+		accnts, err := dryRunAccounts(accountIds, parseAccountIds(commaSeparatedExcludeAccountIds))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+		costsOpt := acos.NewGetCostsOption(asOf)
+		costsOpt.IncludeForecast = view == "summary" && hasColumn(cols, "forecast")
+		costsOpt.AllLinkedAccounts = allLinkedAccounts
+		plan := acos.PlanGetCosts(accnts, costsOpt)
+		if byService {
+			plan = append(plan, acos.PlanGetServiceCosts(accnts, costsOpt)...)
+		}
+		if err := printPlan(os.Stdout, plan); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(6)
+		}
+		return
+	}
+
+	if maxAttempts < 1 || rateLimit < 0 || monthlySpendCap < 0 {
+		fmt.Fprintln(os.Stderr, "error the -maxAttempts flag should be a positive number, and the -rateLimit and -monthlySpendCap flags should not be negative.")
+		os.Exit(2)
+	}
+	acos.Configure(acos.ClientOptions{
+		MaxAttempts:       maxAttempts,
+		MaxBackoff:        maxBackoff,
+		RequestsPerSecond: rateLimit,
+	})
+	dir, err := stateDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	guard := &spendGuard{dir: dir, counter: acos.EnableRequestCounter(), cap: monthlySpendCap, force: force, now: time.Now}
+	var src *acos.LineItemSource
+	if len(source) > 0 {
+		if src, err = openSource(source); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(3)
+		}
+		// No Cost Explorer API requests are made, nor cached.
+		acos.UseDataSource(src)
+		guard.cap, cacheTTL = 0, 0
+	}
+	if demo.Enabled {
+		demo.use()
+		guard.cap, cacheTTL = 0, 0
 	}
 
 	// Choose AWS accounts to show costs
 	ctx := context.Background()
+	start := time.Now()
+	accountsCtx, cancel := withTimeout(ctx, timeout, 0)
+	defer cancel()
 	var candidateAccounts, selectedAccounts acos.Accounts
-	var err error
-	candidateAccounts, err = getAccounts(ctx, GetAccountsOption{
-		AccountIds: accountIds,
-		OuId:       ouId,
-	})
+	accountsOpt := GetAccountsOption{
+		AccountIds:                accountIds,
+		OuId:                      ouId,
+		AccountNamePattern:        accountNamePattern,
+		ExcludeAccountIds:         parseAccountIds(commaSeparatedExcludeAccountIds),
+		ExcludeAccountNamePattern: excludeAccountNamePattern,
+		AccountTags:               tagFilter,
+		ActiveOnly:                activeOnly,
+	}
+	if src != nil {
+		candidateAccounts, err = getSourceAccounts(src.Accounts(), accountsOpt)
+	} else {
+		candidateAccounts, err = getAccounts(accountsCtx, accountsOpt)
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(3)
 	}
-	if len(accountIds) > 0 {
-		// Skip the interactive account selector when the -accountIds flag is set.
+	if len(accountIds) > 0 || allLinkedAccounts {
+		// Skip the interactive account selector when the -accountIds flag or the -allLinkedAccounts flag is set.
+		// With the latter, the accounts are only used to name the accounts in the costs.
 		selectedAccounts = candidateAccounts
 	} else {
-		selectedAccounts, err = promptAccountsSelection(candidateAccounts)
+		interactive := term.IsTerminal(int(os.Stdin.Fd()))
+		var groups *accountGroups
+		if interactive && len(candidateAccounts) > 1 {
+			if groups, err = getAccountGroups(accountsCtx, groupBy, candidateAccounts); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to group the accounts, continuing without groups: %s\n", err.Error())
+			}
+		}
+		// The time in the interactive account selector doesn't count for the -timeout flag.
+		selectStart := time.Now()
+		selectedAccounts, err = selectAccounts(candidateAccounts, groups, selectionName, editSelection, interactive)
+		start = start.Add(time.Since(selectStart))
 	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(4)
 	}
 
+	if watch > 0 && cacheTTL == 0 && src == nil && !demo.Enabled {
+		cacheTTL = defaultWatchCacheTTL
+	}
+	if cacheTTL > 0 {
+		dir, err := acos.DefaultCacheDir()
+		var scope string
+		if err == nil {
+			scope, err = cacheScope(accountsCtx, source)
+		}
+		if err == nil {
+			err = acos.EnableCache(dir, cacheTTL, scope)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to enable the cache, continuing without it: %s\n", err.Error())
+		}
+	}
+
+	// The rest of the run shares the deadline of the -timeout flag with the account retrieval above.
+	fetchCtx, cancel := withTimeout(ctx, timeout, time.Since(start))
+	defer cancel()
+	var ous acos.AccountOus
+	if view == "summary" && hasColumn(cols, "ou") {
+		if ous, err = acos.ListAccountOus(fetchCtx, selectedAccounts.AccountIds()); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(5)
+		}
+	}
+	var tags acos.AccountTags
+	if view == "summary" && len(tagColumnKeys(cols)) > 0 {
+		if tags, err = acos.ListAccountTags(fetchCtx, selectedAccounts.AccountIds()); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(5)
+		}
+	}
+
 	// Get costs
-	var costs acos.Costs
-	if costs, err = acos.GetCosts(ctx, selectedAccounts, acos.NewGetCostsOption(asOf)); err != nil {
+	getCosts := func(ctx context.Context, asOf time.Time) (acos.Costs, error) {
+		costsOpt := acos.NewGetCostsOption(asOf)
+		costsOpt.IncludeForecast = view == "summary" && hasColumn(cols, "forecast")
+		costsOpt.AllLinkedAccounts = allLinkedAccounts
+		if err := guard.check(acos.PlanGetCosts(selectedAccounts, costsOpt)); err != nil {
+			return nil, err
+		}
+		costs, err := acos.GetCosts(ctx, selectedAccounts, costsOpt)
+		if _, err := guard.record(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to record the Cost Explorer API usage: %s\n", err.Error())
+		}
+		var partialErr *acos.PartialError
+		if errors.As(err, &partialErr) {
+			// Show the costs of the other accounts.
+			printPartialError(os.Stderr, partialErr)
+			err = nil
+		}
+		return costs, err
+	}
+	getServiceCosts := func(ctx context.Context, asOf time.Time) ([]acos.BreakdownItem, error) {
+		costsOpt := acos.NewGetCostsOption(asOf)
+		costsOpt.AllLinkedAccounts = allLinkedAccounts
+		// The FOCUS outputs are the usage charges, so that the credits and the refunds are not mixed into them.
+		costsOpt.UsageOnly = isFocusOutput(output)
+		if err := guard.check(acos.PlanGetServiceCosts(selectedAccounts, costsOpt)); err != nil {
+			return nil, err
+		}
+		services, err := acos.GetServiceCosts(ctx, selectedAccounts, costsOpt)
+		if _, err := guard.record(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to record the Cost Explorer API usage: %s\n", err.Error())
+		}
+		return services, err
+	}
+
+	if watch > 0 {
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		w := &watcher{cols: cols, nf: nf}
+		err = runWatch(ctx, os.Stdout, watch, w, func(ctx context.Context) ([]row, time.Time, error) {
+			if len(asOfStr) == 0 {
+				// Follow today, in case the watch mode runs across days.
+				asOf = time.Now().UTC()
+			}
+			// Each refresh has its own deadline of the -timeout flag.
+			ctx, cancel := withTimeout(ctx, timeout, 0)
+			defer cancel()
+			costs, err := getCosts(ctx, asOf)
+			if err != nil {
+				return nil, asOf, err
+			}
+			_, rows := toRows(costs, ous, tags)
+			return rows, asOf, nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(5)
+		}
+		return
+	}
+
+	costs, err := getCosts(fetchCtx, asOf)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(5)
 	}
+	var services []acos.BreakdownItem
+	if byService {
+		if services, err = getServiceCosts(fetchCtx, asOf); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(5)
+		}
+	}
+	if month, err := guard.record(); err == nil && src == nil && !demo.Enabled {
+		printUsage(os.Stderr, guard.recorded, month, usageMonth(guard.now()))
+	}
+	costArray, rows := toRows(costs, ous, tags)
+	if save {
+		q := &snapshotQuery{Profile: awsProfile(), Source: source, OuId: ouId, AllLinkedAccounts: allLinkedAccounts, IncludeForecast: view == "summary" && hasColumn(cols, "forecast")}
+		if demo.Enabled {
+			q.Source = demo.source()
+		}
+		if !allLinkedAccounts {
+			q.AccountIds = sortedAccountIds(selectedAccounts)
+		}
+		id, err := newSnapshotStore(dir).save(snapshot{Query: q, AsOf: asOf, Costs: costArray, Daily: dailyCostsOf(costArray)})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save the snapshot: %s\n", err.Error())
+		} else {
+			fmt.Fprintf(os.Stderr, "Saved the snapshot %s.\n", id)
+		}
+	}
 
+	if isFocusOutput(output) {
+		billingAccountIds, billingAccountNames, err := focusBillingAccounts(fetchCtx, src, candidateAccounts, costArray)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get the billing account: %s\n", err.Error())
+		}
+		printFocus := printFocusCsv
+		if output == "focus-parquet" {
+			printFocus = printFocusParquet
+		}
+		if err := printFocus(os.Stdout, focusRows(costArray, services, billingAccountIds, billingAccountNames)); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(6)
+		}
+		return
+	}
+	if view == "bars" {
+		printBarChart(os.Stdout, costArray, nf)
+		return
+	}
+	if view != "summary" {
+		m := acos.NewDailyCostMatrix(costs)
+		if view == "weekly" {
+			m = acos.NewWeeklyCostMatrix(costs)
+		}
+		switch output {
+		case "json":
+			err = printMatrixJson(m, asOf)
+		case "csv":
+			err = printMatrixCsv(m)
+		default:
+			printMatrixTable(m, asOf, nf)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(6)
+		}
+		return
+	}
+
+	switch output {
+	case "json":
+		if len(commaSeparatedColumns) > 0 {
+			err = printColumnsJson(rows, cols, asOf)
+		} else {
+			err = printJson(costArray, asOf)
+		}
+	case "csv":
+		err = printCsv(rows, cols)
+	default:
+		printTable(os.Stdout, rows, cols, asOf, nf, nil)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(6)
+	}
+}
+
+// toRows returns the costs and the rows sorted by AWS Account ID.
+func toRows(costs acos.Costs, ous acos.AccountOus, tags acos.AccountTags) ([]acos.Cost, []row) {
 	// Sort map keys by AWS Account ID
 	keys := make([]string, 0, len(costs))
 	for k := range costs {
@@ -90,66 +491,83 @@ func main() {
 	sort.Strings(keys)
 	// Map to array
 	costArray := make([]acos.Cost, 0, len(costs))
+	rows := make([]row, 0, len(costs))
 	for _, k := range keys {
-		costArray = append(costArray, (costs)[k])
+		costArray = append(costArray, costs[k])
+		rows = append(rows, row{Cost: costs[k], Ou: ous[k], Tags: tags[k]})
 	}
+	return costArray, rows
+}
 
-	if useJson {
-		if err := printJson(costArray, asOf); err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(6)
-		}
-	} else {
-		// Print table
-		printTable(costArray, comparedTo, asOf)
+// cacheScope returns the scope of the cache, which is the AWS profile, the caller account and the data source,
+// e.g. "default/111111111111/ce", so that the cached responses are never shared between them.
+func cacheScope(ctx context.Context, source string) (string, error) {
+	callerId, err := acos.GetCallerAccountId(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(source) == 0 {
+		source = "ce"
 	}
+	return awsProfile() + "/" + callerId + "/" + source, nil
 }
 
-func printJson(costs []acos.Cost, asOf time.Time) error {
-	jsonStr, err := json.Marshal(struct {
-		AsOf  time.Time
-		Costs []acos.Cost
-	}{asOf, costs})
-	if err != nil {
-		return err
+// addClientFlags adds the flags of the AWS API clients of acos to the flag set of a command.
+func addClientFlags(fs *flag.FlagSet) *acos.ClientOptions {
+	o := &acos.ClientOptions{}
+	fs.IntVar(&o.MaxAttempts, "maxAttempts", 10, "Optional - Same as the -maxAttempts flag of acos.")
+	fs.DurationVar(&o.MaxBackoff, "maxBackoff", 20*time.Second, "Optional - Same as the -maxBackoff flag of acos.")
+	fs.Float64Var(&o.RequestsPerSecond, "rateLimit", 0, "Optional - Same as the -rateLimit flag of acos.")
+	return o
+}
+
+// configureClients configures the AWS API clients with the flags of addClientFlags, or exits when they're invalid.
+func configureClients(o *acos.ClientOptions) {
+	if o.MaxAttempts < 1 || o.RequestsPerSecond < 0 {
+		fmt.Fprintln(os.Stderr, "error the -maxAttempts flag should be a positive number, and the -rateLimit flag should not be negative.")
+		os.Exit(2)
 	}
-	fmt.Println(string(jsonStr))
-	return nil
+	acos.Configure(*o)
 }
 
-func printTable(costs []acos.Cost, comparedTo string, asOf time.Time) {
-	t := tablewriter.NewWriter(os.Stdout)
-	incrHeaderTxt := "vs Yesterday ($)"
-	if comparedTo == "LAST_WEEK" {
-		incrHeaderTxt = "vs Last Week ($)"
-	}
-	t.SetHeader([]string{"Account ID", "Account Name", "This Month ($)", incrHeaderTxt, "Last Month ($)"})
-	t.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT})
-	totalThisMonth, totalIncrease, totalLastMonth := 0.0, 0.0, 0.0
-	for _, c := range costs {
-		thisMonth := fmt.Sprintf("%f", c.AmountThisMonth)
-		incr := c.LatestDailyCostIncrease
-		if comparedTo == "LAST_WEEK" {
-			incr = c.LatestWeeklyCostIncrease
-		}
-		incrStr := fmt.Sprintf("%s %f", getAmountPrefix(incr), incr)
-		lastMonth := fmt.Sprintf("%f", c.AmountLastMonth)
-		t.Append([]string{c.AccountID, c.AccountName, thisMonth, incrStr, lastMonth})
-		totalThisMonth += c.AmountThisMonth
-		totalIncrease += incr
-		totalLastMonth += c.AmountLastMonth
-	}
-	t.SetFooter([]string{"", "Total", fmt.Sprintf("%f", totalThisMonth), fmt.Sprintf("%s %f", getAmountPrefix(totalIncrease), totalIncrease), fmt.Sprintf("%f", totalLastMonth)})
-	t.SetFooterAlignment(tablewriter.ALIGN_RIGHT)
-	t.SetCaption(true, fmt.Sprintf("As of %s.", asOf.Format("2006-01-02")))
-	t.Render()
+// withTimeout returns the context which times out after the -timeout flag value minus the time already spent,
+// or never times out if the flag value is zero.
+func withTimeout(ctx context.Context, timeout, spent time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout-spent)
+	}
+	return context.WithCancel(ctx)
 }
 
-func getAmountPrefix(amount float64) string {
-	if amount > 0.0 {
-		return "+"
-	} else if amount < 0.0 {
-		return "-"
+// printPartialError prints the errors of the accounts which failed to retrieve the costs, in the order of the account IDs.
+func printPartialError(w io.Writer, err *acos.PartialError) {
+	ids := make([]string, 0, len(err.Failures))
+	for id := range err.Failures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(w, "Failed to retrieve the costs of the account %s: %s\n", id, err.Failures[id].Error())
+	}
+}
+
+// parseAsOf returns the date of the -asOf flag value. The default value is today in UTC.
+func parseAsOf(asOfStr string) (time.Time, error) {
+	if len(asOfStr) == 0 {
+		return time.Now().UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", asOfStr)
+	if err != nil {
+		return t, fmt.Errorf("error invalid date format for the --asOf flag. It should be 'YYYY-MM-DD'.")
+	}
+	return t, nil
+}
+
+// parseAccountIds returns the account IDs of the -accountIds or -excludeAccountIds flag value.
+func parseAccountIds(commaSeparatedAccountIds string) []string {
+	var accountIds []string
+	if len(commaSeparatedAccountIds) > 0 {
+		accountIds = splitAccountIds(commaSeparatedAccountIds)
 	}
-	return ""
+	return accountIds
 }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/toricls/acos"
+)
+
+func printJson(costs []acos.Cost, asOf time.Time) error {
+	jsonStr, err := json.Marshal(struct {
+		AsOf  time.Time
+		Costs []acos.Cost
+	}{asOf, costs})
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonStr))
+	return nil
+}
+
+// printColumnsJson prints JSON which only contains the given columns of each row, keyed by the column keys.
+func printColumnsJson(rows []row, cols []column, asOf time.Time) error {
+	costs := make([]map[string]any, len(rows))
+	for i, r := range rows {
+		costs[i] = make(map[string]any, len(cols))
+		for _, c := range cols {
+			costs[i][c.Key] = c.Raw(r)
+		}
+	}
+	jsonStr, err := json.Marshal(struct {
+		AsOf  time.Time
+		Costs []map[string]any
+	}{asOf, costs})
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonStr))
+	return nil
+}
+
+// printCsv prints the given columns of each row in CSV with the raw values.
+// The values are empty when there is nothing to compare with or no forecast.
+func printCsv(rows []row, cols []column) error {
+	w := csv.NewWriter(os.Stdout)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Key
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := make([]string, len(cols))
+		for i, c := range cols {
+			record[i] = formatRaw(c.Raw(r))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// formatRaw formats the raw value of a column without any number formatting.
+func formatRaw(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case *float64:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	case []float64:
+		s := make([]string, len(v))
+		for i, f := range v {
+			s[i] = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		return strings.Join(s, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// cellDecorator returns the decorated cell of the row at the column index, e.g. to highlight it.
+type cellDecorator func(r row, i int, cell string) string
+
+// printTable prints the rows in a table. The `decorate` func is optional.
+func printTable(w io.Writer, rows []row, cols []column, asOf time.Time, nf numberFormat, decorate cellDecorator) {
+	t := tablewriter.NewWriter(w)
+	header := make([]string, len(cols))
+	alignments := make([]int, len(cols))
+	for i, c := range cols {
+		header[i] = c.Header
+		alignments[i] = c.Align
+	}
+	t.SetHeader(header)
+	t.SetColumnAlignment(alignments)
+
+	costs := make(acos.Costs, len(rows))
+	for _, r := range rows {
+		cells := getCells(r, cols, nf)
+		if decorate != nil {
+			for i := range cells {
+				cells[i] = decorate(r, i, cells[i])
+			}
+		}
+		t.Append(cells)
+		costs[r.AccountID] = r.Cost
+	}
+	t.SetFooter(getFooterCells(row{Cost: costs.Total()}, cols, nf))
+	t.SetFooterAlignment(tablewriter.ALIGN_RIGHT)
+	t.SetCaption(true, fmt.Sprintf("As of %s.", asOf.Format("2006-01-02")))
+	t.Render()
+}
+
+func getCells(r row, cols []column, nf numberFormat) []string {
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		cells[i] = c.Cell(r, nf)
+	}
+	return cells
+}
+
+// getFooterCells returns the cells of the total row. The "Total" label is placed right before the first column showing a total.
+func getFooterCells(total row, cols []column, nf numberFormat) []string {
+	cells := make([]string, len(cols))
+	labeled := false
+	for i, c := range cols {
+		if !c.Total {
+			continue
+		}
+		cells[i] = c.Cell(total, nf)
+		if !labeled && i > 0 && !cols[i-1].Total {
+			cells[i-1] = "Total"
+		}
+		labeled = true
+	}
+	return cells
+}
+
+func printMatrixJson(m acos.CostMatrix, asOf time.Time) error {
+	jsonStr, err := json.Marshal(struct {
+		AsOf   time.Time
+		Matrix acos.CostMatrix
+	}{asOf, m})
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(jsonStr))
+	return nil
+}
+
+// printMatrixCsv prints the matrix in CSV with the raw amounts. The last row is the total of each period.
+func printMatrixCsv(m acos.CostMatrix) error {
+	w := csv.NewWriter(os.Stdout)
+	header := append(append([]string{"accountId", "accountName"}, m.Periods...), "total")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, r := range m.Rows {
+		if err := w.Write(getMatrixRecord(r.AccountID, r.AccountName, r.Amounts, r.Total, formatRaw)); err != nil {
+			return err
+		}
+	}
+	if err := w.Write(getMatrixRecord("", "Total", m.PeriodTotals, m.Total, formatRaw)); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printMatrixTable(m acos.CostMatrix, asOf time.Time, nf numberFormat) {
+	t := tablewriter.NewWriter(os.Stdout)
+	header := []string{"Account ID", "Account Name"}
+	for _, p := range m.Periods {
+		// Omit the year of days to keep the table narrow, e.g. "07-18".
+		if len(p) == len("2006-01-02") {
+			p = p[len("2006-"):]
+		}
+		header = append(header, p)
+	}
+	t.SetHeader(append(header, "Total"))
+	alignments := make([]int, len(m.Periods)+3)
+	for i := range alignments {
+		alignments[i] = tablewriter.ALIGN_RIGHT
+	}
+	alignments[0], alignments[1] = tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT
+	t.SetColumnAlignment(alignments)
+	format := func(v any) string { return nf.amount(v.(float64)) }
+	for _, r := range m.Rows {
+		t.Append(getMatrixRecord(r.AccountID, r.AccountName, r.Amounts, r.Total, format))
+	}
+	t.SetFooter(getMatrixRecord("", "Total", m.PeriodTotals, m.Total, format))
+	t.SetFooterAlignment(tablewriter.ALIGN_RIGHT)
+	t.SetCaption(true, fmt.Sprintf("As of %s.", asOf.Format("2006-01-02")))
+	t.Render()
+}
+
+func getMatrixRecord(accountId, accountName string, amounts []float64, total float64, format func(v any) string) []string {
+	record := []string{accountId, accountName}
+	for _, a := range amounts {
+		record = append(record, format(a))
+	}
+	return append(record, format(total))
+}
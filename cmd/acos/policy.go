@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// policyFlags represents the flags of acos which change the required IAM actions.
+type policyFlags struct {
+	OuId              string
+	Columns           []column
+	GroupBy           string
+	AccountTag        string
+	AllLinkedAccounts bool
+	Output            string
+	Standalone        bool // The AWS account is not part of AWS Organizations organization.
+	All               bool // Every feature.
+}
+
+// feature represents a feature of acos and the IAM actions it requires.
+type feature struct {
+	Name    string
+	Actions []string
+	Used    func(f policyFlags) bool
+}
+
+// features represents the registry of all the features and their IAM actions.
+// Add the actions here when a feature starts calling a new AWS API.
+var features = []feature{
+	{
+		Name:    "costs",
+		Actions: []string{"ce:GetCostAndUsage"},
+		Used:    func(f policyFlags) bool { return true },
+	},
+	{
+		Name:    "accounts in the organization",
+		Actions: []string{"organizations:ListAccounts"},
+		Used:    func(f policyFlags) bool { return !f.Standalone && len(f.OuId) == 0 },
+	},
+	{
+		Name:    "-ou",
+		Actions: []string{"organizations:ListAccountsForParent"},
+		Used:    func(f policyFlags) bool { return !f.Standalone && len(f.OuId) > 0 },
+	},
+	{
+		Name:    "the caller account outside organizations",
+		Actions: []string{"sts:GetCallerIdentity", "iam:ListAccountAliases"},
+		Used:    func(f policyFlags) bool { return f.Standalone },
+	},
+	{
+		Name:    "forecast column",
+		Actions: []string{"ce:GetCostForecast"},
+		Used:    func(f policyFlags) bool { return hasColumn(f.Columns, "forecast") },
+	},
+	{
+		Name:    "ou column and -groupBy ou",
+		Actions: []string{"organizations:ListParents", "organizations:DescribeOrganizationalUnit"},
+		Used:    func(f policyFlags) bool { return !f.Standalone && (hasColumn(f.Columns, "ou") || f.GroupBy == "ou") },
+	},
+	{
+		Name:    "tag columns, -groupBy tag:<key> and -accountTag",
+		Actions: []string{"organizations:ListTagsForResource"},
+		Used: func(f policyFlags) bool {
+			return !f.Standalone && (len(tagColumnKeys(f.Columns)) > 0 || strings.HasPrefix(f.GroupBy, tagColumnPrefix) || len(f.AccountTag) > 0)
+		},
+	},
+	{
+		Name:    "-output focus and focus-parquet",
+		Actions: []string{"sts:GetCallerIdentity"},
+		Used:    func(f policyFlags) bool { return isFocusOutput(f.Output) },
+	},
+	{
+		Name:    "-allLinkedAccounts",
+		Actions: []string{"ce:GetDimensionValues"},
+		Used:    func(f policyFlags) bool { return f.AllLinkedAccounts },
+	},
+}
+
+// requiredActions returns the sorted IAM actions required for the flags.
+func requiredActions(f policyFlags) []string {
+	set := make(map[string]bool)
+	for _, ft := range features {
+		if f.All || ft.Used(f) {
+			for _, a := range ft.Actions {
+				set[a] = true
+			}
+		}
+	}
+	actions := make([]string, 0, len(set))
+	for a := range set {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// iamPolicy represents an IAM policy document.
+type iamPolicy struct {
+	Version   string
+	Statement []iamStatement
+}
+
+type iamStatement struct {
+	Sid      string
+	Effect   string
+	Action   []string
+	Resource string
+}
+
+// printIamPolicy prints the IAM policy document which allows the actions.
+func printIamPolicy(w io.Writer, actions []string) error {
+	p := iamPolicy{
+		Version: "2012-10-17",
+		Statement: []iamStatement{
+			{Sid: "Acos", Effect: "Allow", Action: actions, Resource: "*"},
+		},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// runIamPolicy runs the "iam-policy" command, which prints the least-privilege IAM policy for the flags.
+func runIamPolicy(args []string) {
+	fs := flag.NewFlagSet("acos iam-policy", flag.ExitOnError)
+	var f policyFlags
+	var commaSeparatedColumns string
+	fs.StringVar(&f.OuId, "ou", "", "Optional - Same as the -ou flag of acos.")
+	fs.StringVar(&commaSeparatedColumns, "columns", "", "Optional - Same as the -columns flag of acos.")
+	fs.StringVar(&f.GroupBy, "groupBy", "", "Optional - Same as the -groupBy flag of acos.")
+	fs.StringVar(&f.AccountTag, "accountTag", "", "Optional - Same as the -accountTag flag of acos.")
+	fs.BoolVar(&f.AllLinkedAccounts, "allLinkedAccounts", false, "Optional - Same as the -allLinkedAccounts flag of acos.")
+	fs.StringVar(&f.Output, "output", "table", "Optional - Same as the -output flag of acos.")
+	fs.BoolVar(&f.Standalone, "standalone", false, "Optional - The AWS account is not part of AWS Organizations organization.")
+	fs.BoolVar(&f.All, "all", false, "Optional - Allow every feature of acos, including 'acos doctor'.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of acos iam-policy:\n  acos iam-policy [flags]\n\nPrints the least-privilege IAM policy for running acos with the flags.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if len(commaSeparatedColumns) > 0 {
+		cols, err := parseColumns(commaSeparatedColumns)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+		f.Columns = cols
+	}
+	if err := validateGroupBy(f.GroupBy); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	if err := printIamPolicy(os.Stdout, requiredActions(f)); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(6)
+	}
+}
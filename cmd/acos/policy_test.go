@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func Test_requiredActions(t *testing.T) {
+	mustParseColumns := func(keys string) []column {
+		cols, err := parseColumns(keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cols
+	}
+	tests := []struct {
+		name  string
+		flags policyFlags
+		want  []string
+	}{
+		{
+			name:  "default",
+			flags: policyFlags{},
+			want:  []string{"ce:GetCostAndUsage", "organizations:ListAccounts"},
+		},
+		{
+			name:  "ou",
+			flags: policyFlags{OuId: "ou-abcd-12345678"},
+			want:  []string{"ce:GetCostAndUsage", "organizations:ListAccountsForParent"},
+		},
+		{
+			name:  "forecast and ou columns",
+			flags: policyFlags{Columns: mustParseColumns("accountName,forecast,ou")},
+			want:  []string{"ce:GetCostAndUsage", "ce:GetCostForecast", "organizations:DescribeOrganizationalUnit", "organizations:ListAccounts", "organizations:ListParents"},
+		},
+		{
+			name:  "tag column",
+			flags: policyFlags{Columns: mustParseColumns("accountName,tag:team")},
+			want:  []string{"ce:GetCostAndUsage", "organizations:ListAccounts", "organizations:ListTagsForResource"},
+		},
+		{
+			name:  "groupBy tag",
+			flags: policyFlags{GroupBy: "tag:team"},
+			want:  []string{"ce:GetCostAndUsage", "organizations:ListAccounts", "organizations:ListTagsForResource"},
+		},
+		{
+			name:  "accountTag",
+			flags: policyFlags{AccountTag: "env=prod"},
+			want:  []string{"ce:GetCostAndUsage", "organizations:ListAccounts", "organizations:ListTagsForResource"},
+		},
+		{
+			name:  "allLinkedAccounts",
+			flags: policyFlags{AllLinkedAccounts: true},
+			want:  []string{"ce:GetCostAndUsage", "ce:GetDimensionValues", "organizations:ListAccounts"},
+		},
+		{
+			name:  "focus output",
+			flags: policyFlags{Output: "focus"},
+			want:  []string{"ce:GetCostAndUsage", "organizations:ListAccounts", "sts:GetCallerIdentity"},
+		},
+		{
+			name:  "standalone",
+			flags: policyFlags{Standalone: true, Columns: mustParseColumns("accountName,ou")},
+			want:  []string{"ce:GetCostAndUsage", "iam:ListAccountAliases", "sts:GetCallerIdentity"},
+		},
+		{
+			name:  "all",
+			flags: policyFlags{All: true},
+			want: []string{
+				"ce:GetCostAndUsage", "ce:GetCostForecast", "ce:GetDimensionValues", "iam:ListAccountAliases",
+				"organizations:DescribeOrganizationalUnit", "organizations:ListAccounts", "organizations:ListAccountsForParent",
+				"organizations:ListParents", "organizations:ListTagsForResource", "sts:GetCallerIdentity",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := requiredActions(tt.flags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requiredActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_printIamPolicy(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printIamPolicy(&buf, []string{"ce:GetCostAndUsage"}); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"Version": "2012-10-17",
+		"Statement": []any{
+			map[string]any{"Sid": "Acos", "Effect": "Allow", "Action": []any{"ce:GetCostAndUsage"}, "Resource": "*"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("printIamPolicy() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/toricls/acos"
+)
+
+const selectionsFileName = "selections.json"
+
+// stateDir returns the directory to store the state of acos, such as the account selections.
+// It's "$XDG_CONFIG_HOME/acos" on Linux by default, and can be overridden by the ACOS_STATE_DIR environment variable.
+func stateDir() (string, error) {
+	if dir := os.Getenv("ACOS_STATE_DIR"); len(dir) > 0 {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "acos"), nil
+}
+
+// selections represents the account selections of a scope, which is a pair of an AWS profile and an organization.
+type selections struct {
+	Last  []string            `json:",omitempty"` // The account IDs selected last time.
+	Named map[string][]string `json:",omitempty"` // The account IDs saved under the names by the -selection flag.
+}
+
+// selectionStore persists the account selections per scope in a JSON file.
+type selectionStore struct {
+	path   string
+	scopes map[string]*selections
+}
+
+// loadSelectionStore reads the selections file in the directory. It returns an empty store when the file doesn't exist.
+func loadSelectionStore(dir string) (*selectionStore, error) {
+	s := &selectionStore{
+		path:   filepath.Join(dir, selectionsFileName),
+		scopes: make(map[string]*selections),
+	}
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s.scopes); err != nil {
+		return nil, fmt.Errorf("error failed to parse %s: %w", s.path, err)
+	}
+	return s, nil
+}
+
+// get returns the selections of the scope. The returned value is never nil.
+func (s *selectionStore) get(scope string) *selections {
+	sel, ok := s.scopes[scope]
+	if !ok {
+		sel = &selections{}
+		s.scopes[scope] = sel
+	}
+	return sel
+}
+
+// save writes the selections file. It writes a temporary file and renames it so that the file is never corrupted.
+func (s *selectionStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s.scopes, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), selectionsFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// selectionScope returns the scope of the selections for the accounts.
+// The scope is the AWS profile and the organization ID, e.g. "default/o-a1b2c3d4e5", so that
+// the selections don't get mixed up between organizations.
+func selectionScope(accnts acos.Accounts) string {
+	profile := awsProfile()
+	org := "standalone"
+	for _, a := range accnts {
+		if id := organizationId(a); len(id) > 0 {
+			org = id
+			break
+		}
+	}
+	return profile + "/" + org
+}
+
+// awsProfile returns the AWS profile in use, which is "default" unless the AWS_PROFILE environment variable is set.
+func awsProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); len(profile) > 0 {
+		return profile
+	}
+	return "default"
+}
+
+// organizationId returns the organization ID in the account ARN, e.g. "o-a1b2c3d4e5" of
+// "arn:aws:organizations::111111111111:account/o-a1b2c3d4e5/222222222222".
+// It returns an empty string when the account has no ARN, e.g. it's not part of an organization.
+func organizationId(a acos.Account) string {
+	if a.Arn == nil {
+		return ""
+	}
+	parts := strings.Split(*a.Arn, "/")
+	if len(parts) < 3 || !strings.HasPrefix(parts[1], "o-") {
+		return ""
+	}
+	return parts[1]
+}
+
+// pickAccounts returns the accounts of the account IDs, ignoring the IDs which are not in the accounts anymore.
+func pickAccounts(accnts acos.Accounts, accountIds []string) acos.Accounts {
+	result := make(acos.Accounts)
+	for _, id := range accountIds {
+		if a, ok := accnts[id]; ok {
+			result[id] = a
+		}
+	}
+	return result
+}
+
+// sortedAccountIds returns the account IDs of the accounts in ascending order.
+func sortedAccountIds(accnts acos.Accounts) []string {
+	ids := accnts.AccountIds()
+	sort.Strings(ids)
+	return ids
+}
+
+// selectAccounts chooses the accounts to show costs from the candidates.
+// It prompts the user with the last selection preselected when `interactive` is true, and selects all the candidates otherwise.
+// When the selection `name` is set and it has been saved, it uses the saved selection without prompting unless `edit` is true.
+// The selection is saved as the last one when the user is prompted, and under the name if it's set. The accounts are grouped in the prompt by `groups` if it's set.
+func selectAccounts(candidates acos.Accounts, groups *accountGroups, name string, edit, interactive bool) (acos.Accounts, error) {
+	var store *selectionStore
+	dir, err := stateDir()
+	if err == nil {
+		store, err = loadSelectionStore(dir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load the saved account selections, continuing without them: %s\n", err.Error())
+	}
+	sel := &selections{}
+	if store != nil {
+		sel = store.get(selectionScope(candidates))
+	}
+
+	defaults := sel.Last
+	if len(name) > 0 {
+		ids, ok := sel.Named[name]
+		if ok && (!edit || !interactive) {
+			picked := pickAccounts(candidates, ids)
+			if len(picked) == 0 {
+				return nil, fmt.Errorf("error none of the accounts in the selection '%s' are found", name)
+			}
+			fmt.Fprintf(os.Stderr, "Using the saved selection '%s' of %d accounts...\n", name, len(picked))
+			return picked, nil
+		} else if !ok && !interactive {
+			return nil, fmt.Errorf("error the selection '%s' is not found. Run acos in a terminal to save it", name)
+		}
+		if ok {
+			defaults = ids
+		}
+	}
+
+	if !interactive {
+		// Skip the interactive account selector when stdin is not a terminal, e.g. in cron jobs and pipes.
+		fmt.Fprintf(os.Stderr, "Stdin is not a terminal. Selecting all the %d matching accounts...\n", len(candidates))
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("%w found", acos.ErrNoAccounts)
+		}
+		return candidates, nil
+	}
+	selected, err := promptAccountsSelection(candidates, defaults, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	// The prompt is skipped when there's only one candidate, e.g. in a one-off run with the -ou flag,
+	// which shouldn't replace the last selection.
+	prompted := len(candidates) > 1
+	if store != nil && (prompted || len(name) > 0) {
+		ids := sortedAccountIds(selected)
+		if prompted {
+			sel.Last = ids
+		}
+		if len(name) > 0 {
+			if sel.Named == nil {
+				sel.Named = make(map[string][]string)
+			}
+			sel.Named[name] = ids
+		}
+		if err := store.save(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save the account selection: %s\n", err.Error())
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toricls/acos"
+)
+
+func Test_selectionScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		accnts  acos.Accounts
+		want    string
+	}{
+		{
+			name:    "organization",
+			profile: "payments",
+			accnts: acos.Accounts{
+				"222222222222": acos.Account{Id: toPointer("222222222222"), Arn: toPointer("arn:aws:organizations::111111111111:account/o-a1b2c3d4e5/222222222222")},
+			},
+			want: "payments/o-a1b2c3d4e5",
+		},
+		{
+			name:   "standalone account with the default profile",
+			accnts: acos.Accounts{"222222222222": acos.Account{Id: toPointer("222222222222")}},
+			want:   "default/standalone",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_PROFILE", tt.profile)
+			if got := selectionScope(tt.accnts); got != tt.want {
+				t.Errorf("selectionScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_selectionStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := loadSelectionStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.get("default/o-a1b2c3d4e5").Last = []string{"111111111111"}
+	s.get("default/o-a1b2c3d4e5").Named = map[string][]string{"payments-team": {"222222222222", "333333333333"}}
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadSelectionStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.scopes, s.scopes) {
+		t.Errorf("loadSelectionStore() = %v, want %v", loaded.scopes, s.scopes)
+	}
+	if got := loaded.get("other/o-zzzzzzzzzz"); got.Last != nil || got.Named != nil {
+		t.Errorf("get() of an unknown scope = %v, want empty", got)
+	}
+}
+
+func Test_selectAccounts_nonInteractive(t *testing.T) {
+	t.Setenv("ACOS_STATE_DIR", t.TempDir())
+	t.Setenv("AWS_PROFILE", "")
+	candidates := acos.Accounts{
+		"111111111111": acos.Account{Id: toPointer("111111111111"), Name: toPointer("payments-dev")},
+		"222222222222": acos.Account{Id: toPointer("222222222222"), Name: toPointer("payments-prod")},
+		"333333333333": acos.Account{Id: toPointer("333333333333"), Name: toPointer("sandbox")},
+	}
+
+	got, err := selectAccounts(candidates, nil, "", false, false)
+	if err != nil || !reflect.DeepEqual(got, candidates) {
+		t.Errorf("selectAccounts() = %v, %v, want all the candidates", got, err)
+	}
+	if _, err := selectAccounts(candidates, nil, "payments-team", false, false); err == nil {
+		t.Errorf("selectAccounts() with an unsaved selection should fail")
+	}
+
+	dir, _ := stateDir()
+	s, _ := loadSelectionStore(dir)
+	s.get("default/standalone").Named = map[string][]string{"payments-team": {"111111111111", "222222222222", "444444444444"}}
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+	got, err = selectAccounts(candidates, nil, "payments-team", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := sortedAccountIds(got); !reflect.DeepEqual(ids, []string{"111111111111", "222222222222"}) {
+		t.Errorf("selectAccounts() = %v, want the saved accounts which still exist", ids)
+	}
+}
+
+func Test_selectAccounts_singleCandidate(t *testing.T) {
+	t.Setenv("ACOS_STATE_DIR", t.TempDir())
+	t.Setenv("AWS_PROFILE", "")
+	dir, _ := stateDir()
+	s, _ := loadSelectionStore(dir)
+	s.get("default/standalone").Last = []string{"111111111111", "222222222222"}
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The prompt is skipped for the only candidate, which doesn't replace the last selection.
+	candidates := acos.Accounts{"333333333333": acos.Account{Id: toPointer("333333333333"), Name: toPointer("sandbox")}}
+	got, err := selectAccounts(candidates, nil, "", false, true)
+	if err != nil || !reflect.DeepEqual(got, candidates) {
+		t.Errorf("selectAccounts() = %v, %v, want the only candidate", got, err)
+	}
+	loaded, err := loadSelectionStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := loaded.get("default/standalone").Last; !reflect.DeepEqual(last, []string{"111111111111", "222222222222"}) {
+		t.Errorf("the last selection = %v, want the one before", last)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmps) > 0 {
+		t.Errorf("temporary files = %v, want none", tmps)
+	}
+}
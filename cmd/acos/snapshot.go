@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+const (
+	snapshotsDirName = "snapshots"
+	// snapshotIdFormat is the format of the snapshot IDs, which are the times when the snapshots are saved in UTC.
+	snapshotIdFormat = "20060102T150405Z"
+	latestSnapshotId = "latest"
+)
+
+// snapshot represents the results of a run saved by the -save flag.
+// It's a superset of the JSON output, so that the JSON output can be read as a snapshot without the ID and the query.
+type snapshot struct {
+	ID        string         `json:",omitempty"`
+	CreatedAt *time.Time     `json:",omitempty"`
+	Query     *snapshotQuery `json:",omitempty"`
+	AsOf      time.Time
+	Costs     []acos.Cost
+	// Daily is the daily costs of the accounts keyed by the account IDs, which acos.Cost omits in JSON.
+	// It's empty for the snapshots of the closed months. See acos.NewClosedMonthOption.
+	Daily map[string][]acos.DailyCost `json:",omitempty"`
+}
+
+// dailyCostsOf returns the daily costs of the accounts keyed by the account IDs, or nil when there are none.
+func dailyCostsOf(costs []acos.Cost) map[string][]acos.DailyCost {
+	var daily map[string][]acos.DailyCost
+	for _, c := range costs {
+		if len(c.Daily) == 0 {
+			continue
+		}
+		if daily == nil {
+			daily = make(map[string][]acos.DailyCost, len(costs))
+		}
+		daily[c.AccountID] = c.Daily
+	}
+	return daily
+}
+
+// snapshotQuery represents the query of a snapshot.
+type snapshotQuery struct {
+	Profile           string   `json:",omitempty"` // The AWS profile.
+	Source            string   `json:",omitempty"` // The -source flag, e.g. "cur:/path/to/cur".
+	OuId              string   `json:",omitempty"`
+	AccountIds        []string `json:",omitempty"` // The selected accounts.
+	AllLinkedAccounts bool     `json:",omitempty"`
+	IncludeForecast   bool     `json:",omitempty"`
+	Month             string   `json:",omitempty"` // The month of the snapshot saved by the backfill command, e.g. "2023-06".
+}
+
+// sameAccounts returns true when the queries retrieve the costs of the same accounts.
+func (q *snapshotQuery) sameAccounts(other *snapshotQuery) bool {
+	if q == nil || other == nil {
+		return q == other
+	}
+	return q.Profile == other.Profile && q.Source == other.Source && q.OuId == other.OuId && q.AllLinkedAccounts == other.AllLinkedAccounts &&
+		strings.Join(q.AccountIds, ",") == strings.Join(other.AccountIds, ",")
+}
+
+// String returns the short description of the query.
+func (q *snapshotQuery) String() string {
+	if q == nil {
+		return ""
+	}
+	var parts []string
+	if len(q.Profile) > 0 {
+		parts = append(parts, "profile="+q.Profile)
+	}
+	if len(q.Source) > 0 {
+		parts = append(parts, "source="+q.Source)
+	}
+	if len(q.OuId) > 0 {
+		parts = append(parts, "ou="+q.OuId)
+	}
+	if q.AllLinkedAccounts {
+		parts = append(parts, "allLinkedAccounts")
+	} else {
+		parts = append(parts, fmt.Sprintf("%d account(s)", len(q.AccountIds)))
+	}
+	if q.IncludeForecast {
+		parts = append(parts, "forecast")
+	}
+	if len(q.Month) > 0 {
+		parts = append(parts, "backfill="+q.Month)
+	}
+	return strings.Join(parts, " ")
+}
+
+// snapshotStore stores the snapshots as JSON files named by their IDs in a directory.
+type snapshotStore struct {
+	dir string
+	now func() time.Time
+}
+
+func newSnapshotStore(stateDir string) *snapshotStore {
+	return &snapshotStore{dir: filepath.Join(stateDir, snapshotsDirName), now: time.Now}
+}
+
+// save stores the snapshot with a new ID, and returns the ID.
+func (s *snapshotStore) save(snap snapshot) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	id := now.Format(snapshotIdFormat)
+	for i := 2; ; i++ {
+		// Don't overwrite the snapshot saved in the same second.
+		if _, err := os.Stat(s.path(id)); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.Format(snapshotIdFormat), i)
+	}
+	snap.ID, snap.CreatedAt = id, &now
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// Write to a temporary file and rename it, not to leave a broken file.
+	tmp := s.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return "", err
+	}
+	return id, os.Rename(tmp, s.path(id))
+}
+
+func (s *snapshotStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// ids returns the IDs of the snapshots in the order of saving.
+func (s *snapshotStore) ids() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if name := e.Name(); !e.IsDir() && strings.HasSuffix(name, ".json") {
+			ids = append(ids, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	// The IDs of the snapshots saved in the same second have the suffixes, e.g. "-2" and "-10".
+	sort.Slice(ids, func(i, j int) bool {
+		bi, si := splitSnapshotId(ids[i])
+		bj, sj := splitSnapshotId(ids[j])
+		if bi != bj {
+			return bi < bj
+		}
+		return si < sj
+	})
+	return ids, nil
+}
+
+// splitSnapshotId returns the time part and the numeric suffix of the snapshot ID.
+func splitSnapshotId(id string) (string, int) {
+	base, suffix, ok := strings.Cut(id, "-")
+	if !ok {
+		return id, 1
+	}
+	n, err := strconv.Atoi(suffix)
+	if err != nil {
+		return id, 0
+	}
+	return base, n
+}
+
+// load returns the snapshot of the ID. The ID "latest" means the last saved snapshot.
+func (s *snapshotStore) load(id string) (*snapshot, error) {
+	if id == latestSnapshotId {
+		ids, err := s.ids()
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, errors.New("error no snapshots saved. Run acos with the -save flag to save one.")
+		}
+		id = ids[len(ids)-1]
+	}
+	if strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("error invalid snapshot ID \"%s\"", id)
+	}
+	snap, err := readSnapshotFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error snapshot \"%s\" not found. Run 'acos history list' to see the snapshots.", id)
+	}
+	return snap, err
+}
+
+// readSnapshotFile reads a snapshot, or the JSON output of acos.
+func readSnapshotFile(path string) (*snapshot, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, fmt.Errorf("error failed to parse %s: %w", path, err)
+	}
+	// The other JSON outputs, i.e. of the -columns and -view flags, are decoded without errors but without the costs.
+	var raw map[string]json.RawMessage
+	var costs []map[string]json.RawMessage
+	_ = json.Unmarshal(b, &raw)
+	_, valid := raw["Costs"]
+	_ = json.Unmarshal(raw["Costs"], &costs)
+	for _, c := range costs {
+		if _, ok := c["AccountID"]; !ok {
+			valid = false
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("error %s is neither a snapshot nor the JSON output of acos without the -columns and -view flags", path)
+	}
+	return &snap, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+func Test_snapshotStore(t *testing.T) {
+	store := newSnapshotStore(t.TempDir())
+	now := time.Date(2023, 7, 18, 9, 30, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	if _, err := store.load(latestSnapshotId); err == nil {
+		t.Error("load(latest) with no snapshots error = nil, want an error")
+	}
+
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	first := snapshot{
+		Query: &snapshotQuery{Profile: "default", AccountIds: []string{"111111111111"}},
+		AsOf:  asOf,
+		Costs: []acos.Cost{{AccountID: "111111111111", AccountName: "dev", AmountThisMonth: 10}},
+	}
+	ids := []string{}
+	for i := 0; i < 2; i++ {
+		// The second one is saved in the same second.
+		id, err := store.save(first)
+		if err != nil {
+			t.Fatalf("save() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+	now = now.Add(time.Hour)
+	second := first
+	second.Costs = []acos.Cost{{AccountID: "111111111111", AccountName: "dev", AmountThisMonth: 12}}
+	id, err := store.save(second)
+	if err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+	ids = append(ids, id)
+
+	if want := []string{"20230718T093000Z", "20230718T093000Z-2", "20230718T103000Z"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("save() IDs = %v, want %v", ids, want)
+	}
+	if got, err := store.ids(); err != nil || !reflect.DeepEqual(got, ids) {
+		t.Errorf("ids() = %v, %v, want %v", got, err, ids)
+	}
+
+	latest, err := store.load(latestSnapshotId)
+	if err != nil {
+		t.Fatalf("load(latest) error = %v", err)
+	}
+	if latest.ID != "20230718T103000Z" || latest.Costs[0].AmountThisMonth != 12 || !latest.AsOf.Equal(asOf) {
+		t.Errorf("load(latest) = %+v", latest)
+	}
+	if got := summarizeSnapshot(latest); got != (snapshotSummary{ID: "20230718T103000Z", AsOf: "2023-07-18", Accounts: 1, AmountThisMonth: 12, Query: "profile=default 1 account(s)"}) {
+		t.Errorf("summarizeSnapshot() = %+v", got)
+	}
+	backfilled := &snapshot{
+		ID:    "20230718T103001Z",
+		Query: &snapshotQuery{Profile: "default", Month: "2023-06"},
+		AsOf:  time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+		Costs: []acos.Cost{{AccountID: "111111111111", AmountLastMonth: 300}},
+	}
+	if got := summarizeSnapshot(backfilled); got.AmountThisMonth != 300 {
+		t.Errorf("summarizeSnapshot() of the backfilled snapshot = %+v, want the cost of the month", got)
+	}
+
+	for _, id := range []string{"20990101T000000Z", "../selections"} {
+		if _, err := store.load(id); err == nil {
+			t.Errorf("load(%s) error = nil, want an error", id)
+		}
+	}
+}
+
+func Test_snapshotStore_idsInTheSameSecond(t *testing.T) {
+	store := newSnapshotStore(t.TempDir())
+	now := time.Date(2023, 7, 18, 9, 30, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	var want []string
+	for i := 0; i < 11; i++ {
+		id, err := store.save(snapshot{AsOf: now})
+		if err != nil {
+			t.Fatalf("save() error = %v", err)
+		}
+		want = append(want, id)
+	}
+	if got, err := store.ids(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ids() = %v, %v, want %v", got, err, want)
+	}
+	if latest, err := store.load(latestSnapshotId); err != nil || latest.ID != "20230718T093000Z-11" {
+		t.Errorf("load(latest) = %v, %v, want 20230718T093000Z-11", latest, err)
+	}
+}
+
+func Test_readSnapshotFile_jsonOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{
+			// The JSON output of acos without the ID and the query.
+			name:    "costs",
+			content: `{"AsOf":"2023-07-18T00:00:00Z","Costs":[{"AccountID":"111111111111","AccountName":"dev","AmountThisMonth":10}]}`,
+		},
+		{
+			name:    "columns",
+			content: `{"AsOf":"2023-07-18T00:00:00Z","Costs":[{"accountId":"111111111111","accountName":"dev","thisMonth":10}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "matrix",
+			content: `{"AsOf":"2023-07-18T00:00:00Z","Matrix":{"Periods":["2023-07-17"],"Rows":[{"AccountID":"111111111111","AccountName":"dev","Amounts":[10],"Total":10}],"PeriodTotals":[10],"Total":10}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "costs.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			snap, err := readSnapshotFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readSnapshotFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(snap.ID) > 0 || snap.Query != nil || len(snap.Costs) != 1 || snap.Costs[0].AmountThisMonth != 10 {
+				t.Errorf("readSnapshotFile() = %+v", snap)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/toricls/acos"
+)
+
+const curSourcePrefix = "cur:"
+
+// openSource returns the data source of the -source flag, e.g. "cur:/path/to/cur".
+func openSource(source string) (*acos.LineItemSource, error) {
+	path, ok := strings.CutPrefix(source, curSourcePrefix)
+	if !ok || len(path) == 0 {
+		return nil, fmt.Errorf("error invalid value for the -source flag. It should be 'cur:<path>' to read the Cost and Usage Report files.")
+	}
+	return acos.LoadCurFiles(path)
+}
+
+// getSourceAccounts returns the accounts in the data source instead of AWS Organizations, filtered by the options.
+func getSourceAccounts(accnts acos.Accounts, opt GetAccountsOption) (acos.Accounts, error) {
+	if len(opt.AccountIds) > 0 {
+		picked := make(acos.Accounts, len(opt.AccountIds))
+		for _, id := range opt.AccountIds {
+			if a, ok := accnts[id]; ok {
+				picked[id] = a
+			} else {
+				fmt.Fprintf(os.Stderr, "Account ID '%s' is not found in the data source\n", id)
+			}
+		}
+		accnts = picked
+	}
+	return filterAccounts(accnts, opt)
+}
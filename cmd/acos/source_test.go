@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/toricls/acos"
+)
+
+func Test_openSource(t *testing.T) {
+	dir := t.TempDir()
+	csv := "line_item_usage_account_id,line_item_usage_account_name,line_item_usage_start_date,line_item_unblended_cost\n" +
+		"111111111111,myproduct-dev,2023-07-01T00:00:00Z,1.5\n" +
+		"222222222222,myproduct-prod,2023-07-01T00:00:00Z,2.5\n"
+	if err := os.WriteFile(filepath.Join(dir, "cur.csv"), []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := openSource("cur:" + dir)
+	if err != nil {
+		t.Fatalf("openSource() error = %v", err)
+	}
+	if got := len(src.Accounts()); got != 2 {
+		t.Errorf("openSource() = %d accounts, want 2", got)
+	}
+	for _, s := range []string{dir, "cur:", "s3://bucket/cur"} {
+		if _, err := openSource(s); err == nil {
+			t.Errorf("openSource(%q) error = nil, want error", s)
+		}
+	}
+}
+
+func Test_getSourceAccounts(t *testing.T) {
+	accnts := acos.Accounts{
+		"111111111111": acos.Account{Id: toPointer("111111111111"), Name: toPointer("myproduct-dev")},
+		"222222222222": acos.Account{Id: toPointer("222222222222"), Name: toPointer("myproduct-prod")},
+		"333333333333": acos.Account{Id: toPointer("333333333333"), Name: toPointer("sandbox")},
+	}
+	tests := []struct {
+		name string
+		opt  GetAccountsOption
+		want []string
+	}{
+		{
+			name: "all accounts",
+			opt:  GetAccountsOption{},
+			want: []string{"111111111111", "222222222222", "333333333333"},
+		},
+		{
+			name: "account IDs, ignoring the ones not in the data source",
+			opt:  GetAccountsOption{AccountIds: []string{"111111111111", "333333333333", "444444444444"}},
+			want: []string{"111111111111", "333333333333"},
+		},
+		{
+			name: "account IDs and filters",
+			opt:  GetAccountsOption{AccountIds: []string{"111111111111", "222222222222"}, ExcludeAccountNamePattern: "*-dev"},
+			want: []string{"222222222222"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getSourceAccounts(accnts, tt.opt)
+			if err != nil {
+				t.Fatalf("getSourceAccounts() error = %v", err)
+			}
+			ids := got.AccountIds()
+			sort.Strings(ids)
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("getSourceAccounts() = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
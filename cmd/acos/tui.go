@@ -0,0 +1,584 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/toricls/acos"
+)
+
+// tuiLevel represents a level of the drill-down in the TUI.
+type tuiLevel int
+
+const (
+	tuiLevelAccounts tuiLevel = iota
+	tuiLevelServices
+	tuiLevelUsageTypes
+	tuiLevelDaily
+)
+
+func (l tuiLevel) String() string {
+	return [...]string{"Accounts", "Services", "Usage Types", "Daily"}[l]
+}
+
+// tuiSortKey represents the sort order of the list in the TUI.
+type tuiSortKey int
+
+const (
+	tuiSortByAmount tuiSortKey = iota // This month's cost in descending order.
+	tuiSortByChange                   // The increase of the comparison mode in descending order.
+	tuiSortByName
+)
+
+func (s tuiSortKey) String() string {
+	return [...]string{"amount", "change", "name"}[s]
+}
+
+// tuiMode represents the columns shown in the TUI.
+type tuiMode int
+
+const (
+	tuiModeMetrics    tuiMode = iota // This month, last month and the month-to-date change.
+	tuiModeComparison                // The increases and changes vs yesterday and last week.
+)
+
+func (m tuiMode) String() string {
+	return [...]string{"metrics", "comparison"}[m]
+}
+
+// tuiItem represents a row of the list in the TUI.
+type tuiItem struct {
+	Key   string // The account ID, the service name, the usage type or the date.
+	Label string
+	Cost  acos.Cost
+}
+
+// tuiScreen represents the list of a level and its state.
+type tuiScreen struct {
+	level  tuiLevel
+	items  []tuiItem
+	cursor int // The index of the selected item within the visible items.
+	offset int // The index of the first visible item on the screen.
+	filter string
+}
+
+// tuiLoader loads the items of the level. The `path` contains the selected items of the upper levels.
+// It may return both the items and an error when some of the items failed to load.
+type tuiLoader func(ctx context.Context, level tuiLevel, path []tuiItem) ([]tuiItem, error)
+
+// tuiModel represents the state of the TUI. It's independent from the terminal to be tested easily.
+type tuiModel struct {
+	load      tuiLoader
+	redraw    func() // Called before loading data to show the status.
+	stack     []*tuiScreen
+	sortKey   tuiSortKey
+	mode      tuiMode
+	filtering bool
+	status    string
+	quit      bool
+	nf        numberFormat
+	asOf      time.Time
+}
+
+// tuiColumn represents a numeric column of the list in the TUI.
+type tuiColumn struct {
+	header string
+	cell   func(c acos.Cost, nf numberFormat) string
+}
+
+const tuiColumnWidth = 16
+
+var tuiColumns = map[tuiMode][]tuiColumn{
+	tuiModeMetrics: {
+		{"This Month", func(c acos.Cost, nf numberFormat) string { return nf.amount(c.AmountThisMonth) }},
+		{"Last Month", func(c acos.Cost, nf numberFormat) string { return nf.amount(c.AmountLastMonth) }},
+		{"MTD Change", func(c acos.Cost, nf numberFormat) string { return nf.percent(c.MonthToDateChangePercent) }},
+	},
+	tuiModeComparison: {
+		{"vs Yesterday", func(c acos.Cost, nf numberFormat) string { return nf.signedAmount(c.LatestDailyCostIncrease) }},
+		{"Daily Change", func(c acos.Cost, nf numberFormat) string { return nf.percent(c.DailyChangePercent) }},
+		{"vs Last Week", func(c acos.Cost, nf numberFormat) string { return nf.signedAmount(c.LatestWeeklyCostIncrease) }},
+		{"Weekly Change", func(c acos.Cost, nf numberFormat) string { return nf.percent(c.WeeklyChangePercent) }},
+	},
+}
+
+func newTuiModel(load tuiLoader, asOf time.Time, nf numberFormat) *tuiModel {
+	return &tuiModel{
+		load:   load,
+		redraw: func() {},
+		nf:     nf,
+		asOf:   asOf,
+	}
+}
+
+// init loads the accounts as the first screen.
+func (m *tuiModel) init(ctx context.Context) error {
+	m.stack = []*tuiScreen{{level: tuiLevelAccounts}}
+	return m.reload(ctx, true)
+}
+
+func (m *tuiModel) current() *tuiScreen {
+	return m.stack[len(m.stack)-1]
+}
+
+// path returns the selected items of the upper levels of the current screen.
+func (m *tuiModel) path() []tuiItem {
+	path := []tuiItem{}
+	for _, s := range m.stack[:len(m.stack)-1] {
+		if item, ok := s.selected(m.sortKey); ok {
+			path = append(path, item)
+		}
+	}
+	return path
+}
+
+// visible returns the items of the screen which match the filter, in the sort order.
+func (s *tuiScreen) visible(sortKey tuiSortKey) []tuiItem {
+	items := []tuiItem{}
+	for _, item := range s.items {
+		if strings.Contains(strings.ToLower(item.Label), strings.ToLower(s.filter)) {
+			items = append(items, item)
+		}
+	}
+	if s.level == tuiLevelDaily {
+		// The daily costs are always in chronological order.
+		return items
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		switch sortKey {
+		case tuiSortByChange:
+			return a.Cost.LatestDailyCostIncrease > b.Cost.LatestDailyCostIncrease
+		case tuiSortByName:
+			return a.Label < b.Label
+		default:
+			return a.Cost.AmountThisMonth > b.Cost.AmountThisMonth
+		}
+	})
+	return items
+}
+
+func (s *tuiScreen) selected(sortKey tuiSortKey) (tuiItem, bool) {
+	items := s.visible(sortKey)
+	if s.cursor < 0 || s.cursor >= len(items) {
+		return tuiItem{}, false
+	}
+	return items[s.cursor], true
+}
+
+// reload loads the items of the current screen, and keeps the cursor on the same item if possible.
+// The daily costs are only retrieved again when `refresh` is true.
+func (m *tuiModel) reload(ctx context.Context, refresh bool) error {
+	s := m.current()
+	prev, hadSelection := s.selected(m.sortKey)
+
+	m.status = "Loading..."
+	m.redraw()
+	items, err := m.loadLevel(ctx, s.level, m.path(), refresh)
+	if err != nil && items == nil {
+		m.status = err.Error()
+		return err
+	}
+	m.status = ""
+	if err != nil {
+		// Some of the items failed to load, e.g. the costs of some accounts.
+		m.status = err.Error()
+	}
+	s.items = items
+	s.cursor = 0
+	if hadSelection {
+		for i, item := range s.visible(m.sortKey) {
+			if item.Key == prev.Key {
+				s.cursor = i
+			}
+		}
+	}
+	return nil
+}
+
+// loadLevel loads the items of the level. The daily items are built from the daily costs of the selected usage type,
+// which are retrieved again only when `refresh` is true.
+func (m *tuiModel) loadLevel(ctx context.Context, level tuiLevel, path []tuiItem, refresh bool) ([]tuiItem, error) {
+	if level != tuiLevelDaily {
+		return m.load(ctx, level, path)
+	}
+	parent := path[len(path)-1]
+	if refresh {
+		usageTypes, err := m.load(ctx, tuiLevelUsageTypes, path[:len(path)-1])
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range usageTypes {
+			if item.Key == parent.Key {
+				parent = item
+			}
+		}
+	}
+	items := make([]tuiItem, len(parent.Cost.Daily))
+	for i, d := range parent.Cost.Daily {
+		items[i] = tuiItem{Key: d.Date, Label: d.Date, Cost: acos.Cost{AmountThisMonth: d.Amount}}
+	}
+	return items, nil
+}
+
+// handleKey updates the model for the key. A key is either a character, or a name of a special key such as "up" and "enter".
+func (m *tuiModel) handleKey(ctx context.Context, key string) {
+	s := m.current()
+	if m.filtering {
+		switch key {
+		case "enter":
+			m.filtering = false
+		case "esc":
+			m.filtering = false
+			s.filter = ""
+		case "backspace":
+			if len(s.filter) > 0 {
+				_, size := utf8.DecodeLastRuneInString(s.filter)
+				s.filter = s.filter[:len(s.filter)-size]
+			}
+		case "ctrl-c":
+			m.quit = true
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				s.filter += key
+			}
+		}
+		s.cursor = 0
+		return
+	}
+
+	visible := len(s.visible(m.sortKey))
+	switch key {
+	case "q", "ctrl-c":
+		m.quit = true
+	case "up", "k":
+		s.cursor = max(s.cursor-1, 0)
+	case "down", "j":
+		s.cursor = min(s.cursor+1, max(visible-1, 0))
+	case "pgup":
+		s.cursor = max(s.cursor-10, 0)
+	case "pgdn":
+		s.cursor = min(s.cursor+10, max(visible-1, 0))
+	case "home", "g":
+		s.cursor = 0
+	case "end", "G":
+		s.cursor = max(visible-1, 0)
+	case "enter", "right", "l":
+		m.drillDown(ctx)
+	case "esc", "left", "h", "backspace":
+		if len(m.stack) > 1 {
+			m.stack = m.stack[:len(m.stack)-1]
+			m.status = ""
+		}
+	case "/":
+		m.filtering = true
+	case "s":
+		m.sortKey = (m.sortKey + 1) % 3
+		s.cursor = 0
+	case "m", "tab":
+		m.mode = (m.mode + 1) % 2
+	case "r":
+		_ = m.reload(ctx, true)
+	}
+}
+
+func (m *tuiModel) drillDown(ctx context.Context) {
+	s := m.current()
+	if s.level == tuiLevelDaily {
+		return
+	}
+	if _, ok := s.selected(m.sortKey); !ok {
+		return
+	}
+	m.stack = append(m.stack, &tuiScreen{level: s.level + 1})
+	if err := m.reload(ctx, false); err != nil {
+		// Stay on the upper level to show the error.
+		m.stack = m.stack[:len(m.stack)-1]
+	}
+}
+
+// view returns the screen content in the given size.
+func (m *tuiModel) view(width, height int) []string {
+	s := m.current()
+	lines := []string{}
+
+	crumbs := []string{"acos", s.level.String()}
+	for _, item := range m.path() {
+		crumbs = append(crumbs, item.Label)
+	}
+	lines = append(lines, truncate(strings.Join(crumbs, " > "), width))
+	info := fmt.Sprintf("As of %s | mode: %s | sort: %s", m.asOf.Format("2006-01-02"), m.mode, m.sortKey)
+	if m.filtering || len(s.filter) > 0 {
+		info += fmt.Sprintf(" | filter: %s", s.filter)
+		if m.filtering {
+			info += "_"
+		}
+	}
+	lines = append(lines, truncate(info, width))
+
+	items := s.visible(m.sortKey)
+	listHeight := max(height-5, 1)
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	} else if s.cursor >= s.offset+listHeight {
+		s.offset = s.cursor - listHeight + 1
+	}
+	end := min(s.offset+listHeight, len(items))
+
+	if s.level == tuiLevelDaily {
+		lines = append(lines, m.dailyLines(items, s, end, width)...)
+	} else {
+		cols := tuiColumns[m.mode]
+		labelWidth := max(width-2-len(cols)*(tuiColumnWidth+1), 10)
+		header := "  " + padRight("Name", labelWidth)
+		for _, c := range cols {
+			header += " " + padLeft(c.header, tuiColumnWidth)
+		}
+		lines = append(lines, truncate(strings.ToUpper(header), width))
+		for i := s.offset; i < end; i++ {
+			line := padRight(truncate(items[i].Label, labelWidth), labelWidth)
+			for _, c := range cols {
+				line += " " + padLeft(c.cell(items[i].Cost, m.nf), tuiColumnWidth)
+			}
+			lines = append(lines, m.cursorLine(line, i == s.cursor, width))
+		}
+	}
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, truncate(m.status, width))
+	help := "up/down move  enter drill down  esc back  / filter  s sort  m mode  r refresh  q quit"
+	if m.filtering {
+		help = "type to filter  enter done  esc clear"
+	}
+	return append(lines, truncate(help, width))
+}
+
+// dailyLines returns the lines of the daily bar chart.
+func (m *tuiModel) dailyLines(items []tuiItem, s *tuiScreen, end, width int) []string {
+	highest := 0.0
+	for _, item := range items {
+		highest = math.Max(highest, item.Cost.AmountThisMonth)
+	}
+	amountWidth := tuiColumnWidth
+	barWidth := max(width-2-len("2006-01-02")-amountWidth-3, 1)
+	lines := []string{truncate(strings.ToUpper("  "+padRight("Date", len("2006-01-02"))+" "+padLeft("Cost", amountWidth)), width)}
+	for i := s.offset; i < end; i++ {
+		line := fmt.Sprintf("%s %s  %s", items[i].Label, padLeft(m.nf.amount(items[i].Cost.AmountThisMonth), amountWidth), bar(items[i].Cost.AmountThisMonth, highest, barWidth))
+		lines = append(lines, m.cursorLine(line, i == s.cursor, width))
+	}
+	return lines
+}
+
+func (m *tuiModel) cursorLine(line string, selected bool, width int) string {
+	if selected {
+		// Reverse video for the selected line.
+		return "\x1b[7m" + padRight(truncate("> "+line, width), width) + "\x1b[0m"
+	}
+	return truncate("  "+line, width)
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:max(width, 0)])
+}
+
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0))
+}
+
+func padLeft(s string, width int) string {
+	return strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0)) + s
+}
+
+// parseKeys returns the keys in the input read from the terminal in the raw mode.
+func parseKeys(in []byte) []string {
+	keys := []string{}
+	for i := 0; i < len(in); {
+		b := in[i]
+		switch {
+		case b == 0x1b && i+2 < len(in) && (in[i+1] == '[' || in[i+1] == 'O'):
+			// CSI and SS3 sequences, e.g. "\x1b[A" and "\x1bOA" for the up arrow key.
+			seq, n := string(in[i+2]), 3
+			if in[i+2] >= '0' && in[i+2] <= '9' && i+3 < len(in) && in[i+3] == '~' {
+				seq, n = string(in[i+2:i+4]), 4
+			}
+			name, ok := map[string]string{"A": "up", "B": "down", "C": "right", "D": "left", "H": "home", "F": "end", "5~": "pgup", "6~": "pgdn"}[seq]
+			if ok {
+				keys = append(keys, name)
+			}
+			i += n
+		case b == 0x1b:
+			keys = append(keys, "esc")
+			i++
+		case b == '\r' || b == '\n':
+			keys = append(keys, "enter")
+			i++
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, "backspace")
+			i++
+		case b == '\t':
+			keys = append(keys, "tab")
+			i++
+		case b == 0x03:
+			keys = append(keys, "ctrl-c")
+			i++
+		case b < 0x20:
+			// Ignore the other control characters.
+			i++
+		default:
+			r, size := utf8.DecodeRune(in[i:])
+			keys = append(keys, string(r))
+			i += size
+		}
+	}
+	return keys
+}
+
+// runTuiLoop draws the model on the terminal and handles the keys until the user quits.
+// The terminal must be in the raw mode.
+func runTuiLoop(ctx context.Context, m *tuiModel, in io.Reader, out io.Writer, size func() (int, int)) error {
+	draw := func() {
+		width, height := size()
+		// Move the cursor to home and clear the screen, then draw the lines.
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+strings.Join(m.view(width, height), "\r\n"))
+	}
+	m.redraw = draw
+	if err := m.init(ctx); err != nil {
+		return err
+	}
+	buf := make([]byte, 64)
+	for !m.quit {
+		draw()
+		n, err := in.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			m.handleKey(ctx, key)
+		}
+	}
+	return nil
+}
+
+// runTui runs the "tui" command, the full-screen interactive dashboard.
+func runTui(args []string) {
+	var ouId, asOfStr, commaSeparatedAccountIds string
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	fs.StringVar(&ouId, "ou", "", "Optional - The ID of an AWS Organizational Unit (OU) or Root to list direct-children AWS accounts. It must start with 'ou-' or 'r-' prefix. This flag is ignored when the -accountIds flag is set.")
+	fs.StringVar(&asOfStr, "asOf", "", "Optional - The date to retrieve the cost data. The format should be 'YYYY-MM-DD'. The default value is today in UTC.")
+	fs.StringVar(&commaSeparatedAccountIds, "accountIds", "", "Optional - Comma-separated AWS account IDs to show in the dashboard.")
+	clientOpts := addClientFlags(fs)
+	demo := addDemoFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of acos tui:\n  acos tui [flags]\n\nOpens a full-screen dashboard of the costs of the accounts, which drills down into the services and the usage types.\nIts Cost Explorer API requests are recorded in the usage file, but the -monthlySpendCap flag isn't available,\nbecause the requests depend on how far you drill down.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	asOf, err := parseAsOf(asOfStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Fprintln(os.Stderr, "error the tui command requires a terminal.")
+		os.Exit(2)
+	}
+	nf, _ := newNumberFormat("en-US", "$", 2, false)
+	if !supportsUnicode() {
+		chars = asciiCharset
+	}
+
+	dir, err := stateDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	configureClients(clientOpts)
+	guard := &spendGuard{dir: dir, counter: acos.EnableRequestCounter(), now: time.Now}
+	if demo.Enabled {
+		demo.use()
+	}
+
+	ctx := context.Background()
+	accounts, err := getAccounts(ctx, GetAccountsOption{
+		AccountIds: parseAccountIds(commaSeparatedAccountIds),
+		OuId:       ouId,
+	})
+	if err == nil && len(accounts) == 0 {
+		err = fmt.Errorf("%w found", acos.ErrNoAccounts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(3)
+	}
+
+	opt := acos.NewGetCostsOption(asOf)
+	load := func(ctx context.Context, level tuiLevel, path []tuiItem) ([]tuiItem, error) {
+		if level == tuiLevelAccounts {
+			costs, err := acos.GetCosts(ctx, accounts, opt)
+			var partialErr *acos.PartialError
+			if err != nil && !errors.As(err, &partialErr) {
+				return nil, err
+			}
+			items := make([]tuiItem, 0, len(costs))
+			for id, c := range costs {
+				items = append(items, tuiItem{Key: id, Label: fmt.Sprintf("%s - %s", id, c.AccountName), Cost: c})
+			}
+			return items, err
+		}
+		q := acos.BreakdownQuery{AccountID: path[0].Key, GroupBy: acos.BreakdownByService}
+		if level == tuiLevelUsageTypes {
+			q.GroupBy, q.Service = acos.BreakdownByUsageType, path[1].Key
+		}
+		breakdown, err := acos.GetCostBreakdown(ctx, q, opt)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]tuiItem, len(breakdown))
+		for i, b := range breakdown {
+			items[i] = tuiItem{Key: b.Key, Label: b.Key, Cost: b.Cost}
+		}
+		return items, nil
+	}
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(4)
+	}
+	// Use the alternate screen and hide the cursor, to restore the terminal as it was when quitting.
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	size := func() (int, int) {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			return 80, 24
+		}
+		return width, height
+	}
+	err = runTuiLoop(ctx, newTuiModel(load, asOf, nf), os.Stdin, os.Stdout, size)
+	fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+	term.Restore(int(os.Stdin.Fd()), state)
+	if month, err := guard.record(); err == nil && !demo.Enabled {
+		printUsage(os.Stderr, guard.recorded, month, usageMonth(guard.now()))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(5)
+	}
+}
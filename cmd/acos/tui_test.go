@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+// fakeTuiLoader returns the fixed items for each level, and records the loaded paths.
+type fakeTuiLoader struct {
+	paths [][]string
+}
+
+func (f *fakeTuiLoader) load(ctx context.Context, level tuiLevel, path []tuiItem) ([]tuiItem, error) {
+	keys := []string{}
+	for _, p := range path {
+		keys = append(keys, p.Key)
+	}
+	f.paths = append(f.paths, keys)
+	switch level {
+	case tuiLevelAccounts:
+		return []tuiItem{
+			{Key: "123456789012", Label: "123456789012 - my-sandbox", Cost: acos.Cost{AmountThisMonth: 1, LatestDailyCostIncrease: 0.5}},
+			{Key: "567890123456", Label: "567890123456 - my-prod", Cost: acos.Cost{AmountThisMonth: 100, LatestDailyCostIncrease: 0.1}},
+		}, nil
+	case tuiLevelServices:
+		return []tuiItem{
+			{Key: "Amazon S3", Label: "Amazon S3", Cost: acos.Cost{AmountThisMonth: 10}},
+			{Key: "Amazon EC2", Label: "Amazon EC2", Cost: acos.Cost{AmountThisMonth: 90}},
+		}, nil
+	default:
+		return []tuiItem{
+			{Key: "BoxUsage", Label: "BoxUsage", Cost: acos.Cost{AmountThisMonth: 90, Daily: []acos.DailyCost{{Date: "2023-07-16", Amount: 30}, {Date: "2023-07-17", Amount: 60}}}},
+		}, nil
+	}
+}
+
+func newTestTuiModel(t *testing.T) (*tuiModel, *fakeTuiLoader) {
+	t.Helper()
+	f := &fakeTuiLoader{}
+	nf, _ := newNumberFormat("en-US", "$", 2, false)
+	m := newTuiModel(f.load, time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC), nf)
+	if err := m.init(context.Background()); err != nil {
+		t.Fatalf("tuiModel.init() error = %v", err)
+	}
+	return m, f
+}
+
+func labels(items []tuiItem) []string {
+	l := []string{}
+	for _, item := range items {
+		l = append(l, item.Label)
+	}
+	return l
+}
+
+func Test_tuiModel_drillDown(t *testing.T) {
+	ctx := context.Background()
+	m, f := newTestTuiModel(t)
+	for _, key := range []string{"enter", "enter", "enter"} {
+		m.handleKey(ctx, key)
+	}
+	if got := m.current().level; got != tuiLevelDaily {
+		t.Fatalf("tuiModel level = %v, want %v", got, tuiLevelDaily)
+	}
+	// The most expensive account and service are selected by default.
+	want := [][]string{{}, {"567890123456"}, {"567890123456", "Amazon EC2"}}
+	if !reflect.DeepEqual(f.paths, want) {
+		t.Errorf("tuiModel loaded paths = %v, want %v", f.paths, want)
+	}
+	if got := labels(m.current().visible(m.sortKey)); !reflect.DeepEqual(got, []string{"2023-07-16", "2023-07-17"}) {
+		t.Errorf("tuiModel daily items = %v", got)
+	}
+
+	m.handleKey(ctx, "esc")
+	m.handleKey(ctx, "esc")
+	if got := m.current().level; got != tuiLevelServices {
+		t.Errorf("tuiModel level = %v, want %v", got, tuiLevelServices)
+	}
+	m.handleKey(ctx, "q")
+	if !m.quit {
+		t.Errorf("tuiModel.quit = false, want true")
+	}
+}
+
+func Test_tuiModel_filterAndSort(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestTuiModel(t)
+	if got := labels(m.current().visible(m.sortKey)); !reflect.DeepEqual(got, []string{"567890123456 - my-prod", "123456789012 - my-sandbox"}) {
+		t.Errorf("tuiModel sorted by amount = %v", got)
+	}
+	m.handleKey(ctx, "s")
+	if got := labels(m.current().visible(m.sortKey)); !reflect.DeepEqual(got, []string{"123456789012 - my-sandbox", "567890123456 - my-prod"}) {
+		t.Errorf("tuiModel sorted by change = %v", got)
+	}
+	for _, key := range []string{"/", "P", "r", "x", "backspace", "o", "enter"} {
+		m.handleKey(ctx, key)
+	}
+	if got := labels(m.current().visible(m.sortKey)); !reflect.DeepEqual(got, []string{"567890123456 - my-prod"}) {
+		t.Errorf("tuiModel filtered = %v", got)
+	}
+	if m.filtering {
+		t.Errorf("tuiModel.filtering = true, want false")
+	}
+}
+
+func Test_tuiModel_view(t *testing.T) {
+	m, _ := newTestTuiModel(t)
+	lines := m.view(100, 10)
+	if len(lines) != 10 {
+		t.Errorf("tuiModel.view() returned %d lines, want 10", len(lines))
+	}
+	screen := strings.Join(lines, "\n")
+	for _, want := range []string{"acos > Accounts", "THIS MONTH", "> 567890123456 - my-prod", "$100.00"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("tuiModel.view() doesn't contain %q:\n%s", want, screen)
+		}
+	}
+	m.handleKey(context.Background(), "m")
+	if screen := strings.Join(m.view(100, 10), "\n"); !strings.Contains(screen, "VS YESTERDAY") {
+		t.Errorf("tuiModel.view() doesn't show the comparison mode:\n%s", screen)
+	}
+}
+
+func Test_parseKeys(t *testing.T) {
+	got := parseKeys([]byte("\x1b[A\x1bOBj\r\x1b[5~\x7f\x1bé"))
+	want := []string{"up", "down", "j", "enter", "pgup", "backspace", "esc", "é"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys() = %v, want %v", got, want)
+	}
+}
+
+func TestTuiModel_partialLoad(t *testing.T) {
+	nf, _ := newNumberFormat("en-US", "$", 2, false)
+	partial := &acos.PartialError{Failures: map[string]error{"567890123456": errors.New("error throttled")}}
+	m := newTuiModel(func(ctx context.Context, level tuiLevel, path []tuiItem) ([]tuiItem, error) {
+		return []tuiItem{{Key: "123456789012", Label: "123456789012 - my-sandbox"}}, partial
+	}, time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC), nf)
+	if err := m.init(context.Background()); err != nil {
+		t.Fatalf("tuiModel.init() error = %v", err)
+	}
+	if len(m.current().items) != 1 || m.status != partial.Error() {
+		t.Errorf("tuiModel after a partial load = %d items and status %q, want 1 item and %q", len(m.current().items), m.status, partial.Error())
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+const usageFileName = "usage.json"
+
+// errSpendCapExceeded is returned when running acos would exceed the monthly Cost Explorer API spend cap.
+var errSpendCapExceeded = errors.New("error the monthly Cost Explorer API spend cap would be exceeded")
+
+// usageStore persists the number of the billable Cost Explorer API requests per month, e.g. "2023-07", in a JSON file.
+type usageStore struct {
+	path   string
+	months map[string]int
+}
+
+// loadUsageStore reads the usage file in the directory. It returns an empty store when the file doesn't exist.
+func loadUsageStore(dir string) (*usageStore, error) {
+	s := &usageStore{
+		path:   filepath.Join(dir, usageFileName),
+		months: make(map[string]int),
+	}
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s.months); err != nil {
+		return nil, fmt.Errorf("error failed to parse %s: %w", s.path, err)
+	}
+	return s, nil
+}
+
+// save writes the usage file. It writes a unique temporary file and renames it so that the file is never corrupted.
+// Use updateUsage to modify the file, which locks it against the concurrent runs of acos.
+func (s *usageStore) save() error {
+	b, err := json.MarshalIndent(s.months, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), usageFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+const usageLockTimeout = 10 * time.Second
+
+// updateUsage loads the usage file in the directory, modifies it with `f` and saves it while holding the lock of the
+// lock file, so that the concurrent runs of acos don't lose the counts of each other. The lock is released by the OS
+// when the run crashes, so that it never gets stale.
+func updateUsage(dir string, f func(months map[string]int)) (*usageStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	lock, err := os.OpenFile(filepath.Join(dir, usageFileName+".lock"), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Close()
+	for deadline := time.Now().Add(usageLockTimeout); ; {
+		ok, err := tryLockFile(lock)
+		if err != nil {
+			return nil, fmt.Errorf("error failed to lock %s: %w", lock.Name(), err)
+		} else if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("error failed to lock %s. Another acos may be running", lock.Name())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer unlockFile(lock)
+
+	s, err := loadUsageStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	f(s.months)
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// usageMonth returns the key of the month in the usage file. The Cost Explorer API is billed by the month in UTC.
+func usageMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// spendGuard records the billable Cost Explorer API requests of a run to the usage file,
+// and refuses the requests which would exceed the monthly spend cap.
+type spendGuard struct {
+	dir      string
+	counter  interface{ Count() int } // *acos.RequestCounter
+	cap      float64                  // The monthly spend cap in USD. Zero means no cap.
+	force    bool                     // Ignore the cap.
+	now      func() time.Time
+	recorded int // The number of the requests of this run which are already recorded.
+}
+
+// check returns errSpendCapExceeded when the requests of the plan would exceed the monthly spend cap.
+func (g *spendGuard) check(plan acos.CostsPlan) error {
+	if g.cap <= 0 || g.force {
+		return nil
+	}
+	s, err := loadUsageStore(g.dir)
+	if err != nil {
+		return err
+	}
+	month := usageMonth(g.now())
+	spent := float64(s.months[month]+g.counter.Count()-g.recorded) * acos.CeRequestPrice
+	if spent+plan.Price() > g.cap {
+		return fmt.Errorf("%w: $%.2f spent in %s, and this run would make about %d request(s) ($%.2f) against the cap of $%.2f. Use the -force flag to run anyway.",
+			errSpendCapExceeded, spent, month, plan.Requests(), plan.Price(), g.cap)
+	}
+	return nil
+}
+
+// record adds the requests made since the last call to the usage file, and returns the total number of the requests in this month.
+func (g *spendGuard) record() (int, error) {
+	month := usageMonth(g.now())
+	n := g.counter.Count()
+	if n <= g.recorded {
+		s, err := loadUsageStore(g.dir)
+		if err != nil {
+			return 0, err
+		}
+		return s.months[month], nil
+	}
+	s, err := updateUsage(g.dir, func(months map[string]int) {
+		months[month] += n - g.recorded
+	})
+	if err != nil {
+		return 0, err
+	}
+	g.recorded = n
+	return s.months[month], nil
+}
+
+// printUsage prints the number and the price of the billable requests of this run and of this month.
+func printUsage(w io.Writer, run, month int, monthKey string) {
+	fmt.Fprintf(w, "Cost Explorer API: %d billable request(s) ($%.2f) in this run, %d ($%.2f) in %s.\n",
+		run, float64(run)*acos.CeRequestPrice, month, float64(month)*acos.CeRequestPrice, monthKey)
+}
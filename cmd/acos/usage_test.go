@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+type fakeCounter int
+
+func (c *fakeCounter) Count() int { return int(*c) }
+
+func Test_spendGuard(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	var counter fakeCounter
+	g := &spendGuard{dir: dir, counter: &counter, cap: 0.10, now: func() time.Time { return now }}
+	plan := acos.CostsPlan{{Action: "ce:GetCostAndUsage", Pages: 4}}
+
+	// $0.04 of $0.10.
+	if err := g.check(plan); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+	counter = 4
+	if month, err := g.record(); err != nil || month != 4 {
+		t.Fatalf("record() = %d, %v, want 4", month, err)
+	}
+	// Recording twice doesn't count the same requests again.
+	if month, err := g.record(); err != nil || month != 4 {
+		t.Fatalf("record() = %d, %v, want 4", month, err)
+	}
+
+	// The next run with the same usage file: $0.04 + $0.04 of $0.10, then $0.08 + $0.04 exceeds it.
+	var next fakeCounter
+	g2 := &spendGuard{dir: dir, counter: &next, cap: 0.10, now: g.now}
+	if err := g2.check(plan); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+	next = 4
+	if err := g2.check(plan); !errors.Is(err, errSpendCapExceeded) {
+		t.Errorf("check() error = %v, want errSpendCapExceeded", err)
+	}
+	g2.force = true
+	if err := g2.check(plan); err != nil {
+		t.Errorf("check() with force error = %v", err)
+	}
+	if month, err := g2.record(); err != nil || month != 8 {
+		t.Fatalf("record() = %d, %v, want 8", month, err)
+	}
+
+	// A new month starts from zero.
+	g3 := &spendGuard{dir: dir, counter: new(fakeCounter), cap: 0.10, now: func() time.Time { return now.AddDate(0, 1, 0) }}
+	if err := g3.check(plan); err != nil {
+		t.Errorf("check() in the next month error = %v", err)
+	}
+	s, err := loadUsageStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.months["2023-07"] != 8 || len(s.months) != 1 {
+		t.Errorf("usage file = %v, want 2023-07: 8", s.months)
+	}
+}
+
+func Test_spendGuard_concurrentRuns(t *testing.T) {
+	dir := t.TempDir()
+	now := func() time.Time { return time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC) }
+	// The lock file left by a crashed run, which doesn't hold the lock anymore.
+	if err := os.WriteFile(filepath.Join(dir, usageFileName+".lock"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	const runs = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, runs)
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counter := fakeCounter(3)
+			g := &spendGuard{dir: dir, counter: &counter, now: now}
+			_, err := g.record()
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("record() error = %v", err)
+		}
+	}
+	s, err := loadUsageStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.months["2023-07"]; got != runs*3 {
+		t.Errorf("usage of 2023-07 = %d, want %d", got, runs*3)
+	}
+
+	// Another run can't take the lock while it's held.
+	lock, err := os.OpenFile(filepath.Join(dir, usageFileName+".lock"), os.O_RDWR, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Close()
+	if ok, err := tryLockFile(lock); !ok || err != nil {
+		t.Fatalf("tryLockFile() = %v, %v, want true", ok, err)
+	}
+	other, err := os.OpenFile(lock.Name(), os.O_RDWR, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if ok, err := tryLockFile(other); ok || err != nil {
+		t.Errorf("tryLockFile() of the locked file = %v, %v, want false", ok, err)
+	}
+	if err := unlockFile(lock); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tryLockFile(other); !ok || err != nil {
+		t.Errorf("tryLockFile() of the unlocked file = %v, %v, want true", ok, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("files in the state directory = %v, want only %s and its lock file", entries, usageFileName)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// defaultWatchCacheTTL is the cache TTL in the watch mode unless the -cacheTTL flag is set.
+// The Cost Explorer data is updated at most a few times a day, so that refreshing it more often just costs money.
+const defaultWatchCacheTTL = time.Hour
+
+// watcher redraws the table in place, and highlights the cells changed since the last refresh.
+type watcher struct {
+	cols []column
+	nf   numberFormat
+	prev map[string][]string // map[accountId]cells of the last refresh
+}
+
+// render writes the table of the rows with the changed cells highlighted, and remembers the cells for the next refresh.
+func (w *watcher) render(out io.Writer, rows []row, asOf time.Time) {
+	current := make(map[string][]string, len(rows))
+	decorate := func(r row, i int, cell string) string {
+		if _, ok := current[r.AccountID]; !ok {
+			current[r.AccountID] = make([]string, len(w.cols))
+		}
+		current[r.AccountID][i] = cell
+		if w.prev == nil {
+			// Nothing to compare with at the first refresh.
+			return cell
+		}
+		if prev, ok := w.prev[r.AccountID]; ok && prev[i] == cell {
+			return cell
+		}
+		// Bold yellow for the changed cells.
+		return "\x1b[1;33m" + cell + "\x1b[0m"
+	}
+	printTable(out, rows, w.cols, asOf, w.nf, decorate)
+	w.prev = current
+}
+
+// runWatch re-runs the `fetch` func every interval and redraws the table in place, until the context is done.
+// A failed refresh keeps the last table on the screen and shows the error below it.
+func runWatch(ctx context.Context, out io.Writer, interval time.Duration, w *watcher, fetch func(ctx context.Context) ([]row, time.Time, error)) error {
+	var last bytes.Buffer
+	for {
+		rows, asOf, err := fetch(ctx)
+		if err == nil {
+			last.Reset()
+			w.render(&last, rows, asOf)
+		}
+		// Move the cursor to home and clear the screen, then draw the table.
+		fmt.Fprint(out, "\x1b[H\x1b[2J")
+		out.Write(last.Bytes())
+		if err != nil {
+			fmt.Fprintf(out, "Failed to refresh at %s: %s\n", time.Now().Format("15:04:05"), err.Error())
+		} else {
+			fmt.Fprintf(out, "Updated at %s. Refreshing every %s. Press Ctrl+C to quit.\n", time.Now().Format("15:04:05"), interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
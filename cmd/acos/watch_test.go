@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toricls/acos"
+)
+
+func Test_watcher_render(t *testing.T) {
+	nf, _ := newNumberFormat("en-US", "$", 2, false)
+	cols, _ := parseColumns("accountId,thisMonth,lastMonth")
+	w := &watcher{cols: cols, nf: nf}
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	highlighted := func(cell string) string { return "\x1b[1;33m" + cell + "\x1b[0m" }
+
+	var first bytes.Buffer
+	w.render(&first, []row{{Cost: acos.Cost{AccountID: "123456789012", AmountThisMonth: 1, AmountLastMonth: 2}}}, asOf)
+	if strings.Contains(first.String(), "\x1b[") {
+		t.Errorf("watcher.render() highlights cells at the first refresh:\n%s", first.String())
+	}
+
+	var second bytes.Buffer
+	w.render(&second, []row{
+		{Cost: acos.Cost{AccountID: "123456789012", AmountThisMonth: 1.5, AmountLastMonth: 2}},
+		{Cost: acos.Cost{AccountID: "567890123456", AmountThisMonth: 3, AmountLastMonth: 4}},
+	}, asOf)
+	got := second.String()
+	for _, want := range []string{highlighted("$1.50"), highlighted("567890123456"), highlighted("$4.00")} {
+		if !strings.Contains(got, want) {
+			t.Errorf("watcher.render() doesn't highlight %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, highlighted("$2.00")) || strings.Contains(got, highlighted("123456789012")) {
+		t.Errorf("watcher.render() highlights unchanged cells:\n%s", got)
+	}
+}
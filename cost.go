@@ -3,7 +3,10 @@ package acos
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -25,29 +28,57 @@ func init() {
 	ceClient = costexplorer.NewFromConfig(cfg)
 }
 
+const (
+	// ceAccountBatchSize is the max number of the account IDs in the filter of a GetCostAndUsage request.
+	// Large organizations are split into batches to keep the requests and the paginated responses small.
+	ceAccountBatchSize = 100
+	// ceBatchConcurrency is the max number of the batches fetched concurrently.
+	ceBatchConcurrency = 4
+)
+
 const (
 	ceDataGranularity = "DAILY"
 	ceCostMetric      = "UnblendedCost"
 	ceCostGroupBy     = "LINKED_ACCOUNT"
 )
 
+// usageRecordTypes are the RECORD_TYPE dimension values of the usage charges. See AcosGetCostsOption.UsageOnly.
+var usageRecordTypes = []string{"Usage", "DiscountedUsage", "SavingsPlanCoveredUsage"}
+
 // AcosGetCostsOption represents options for GetCosts. The default values are:
-// - ExcludeCredit : true
-// - ExcludeUpfront: true
-// - ExcludeRefund : false
-// - ExcludeSupport: false
+// - ExcludeCredit  : true
+// - ExcludeUpfront : true
+// - ExcludeRefund  : false
+// - ExcludeSupport : false
+// - IncludeForecast: false
 type AcosGetCostsOption struct {
 	ExcludeCredit  bool
 	ExcludeUpfront bool
 	ExcludeRefund  bool
 	ExcludeSupport bool
+	// IncludeForecast fills Cost.ForecastThisMonth using the GetCostForecast API, which is called for each account.
+	IncludeForecast bool
+	// UsageOnly retrieves only the usage charges, i.e. the usageRecordTypes, instead of excluding the record types
+	// by the Exclude options above. It's for the outputs which tell the usage from the other charges, e.g. credits.
+	UsageOnly bool
+	// AllLinkedAccounts retrieves the costs of all the linked accounts in the Cost Explorer data, not only of the given accounts.
+	// It includes the accounts which left the organization, whose names are resolved by the GetDimensionValues API
+	// and whose Cost.AccountStatus is AccountStatusNotInOrganization.
+	AllLinkedAccounts bool
+
+	// monthly retrieves the costs with the MONTHLY granularity. See NewClosedMonthOption.
+	monthly bool
 
 	// acos requires the following dates to show - THIS_MONTH, vs YESTERDAY, vs LAST_WEEK, and LAST_MONTH
 	dates struct {
 		asOf                string
+		yesterday           string // Just for flagging the day before yesterday's cost
 		oneWeekAgo          string
+		twoWeeksAgo         string // Just for flagging the week before last week's cost
 		firstDayOfLastMonth string
+		sameDayLastMonth    string // The end (exclusive) of the same period as this month-to-date in the last month
 		firstDayOfThisMonth string // Just for flagging within the sum-up logic
+		firstDayOfNextMonth string // Just for the forecast
 	}
 }
 
@@ -57,34 +88,148 @@ func NewGetCostsOption(asOfInUTC time.Time) AcosGetCostsOption {
 		ExcludeUpfront: true,
 		ExcludeRefund:  false,
 		ExcludeSupport: false,
+
+		IncludeForecast: false,
 	}
 
-	oneWeekAgo := asOfInUTC.Add(time.Duration(-7) * 24 * time.Hour)
-	year, month, _ := asOfInUTC.Date()
+	yesterday := asOfInUTC.AddDate(0, 0, -1)
+	oneWeekAgo := asOfInUTC.AddDate(0, 0, -7)
+	twoWeeksAgo := asOfInUTC.AddDate(0, 0, -14)
+	year, month, day := asOfInUTC.Date()
 	firstDayOfThisMonth := time.Date(year, month, 1, 0, 0, 0, 0, asOfInUTC.Location())
 	firstDayOfLastMonth := time.Date(year, month-1, 1, 0, 0, 0, 0, asOfInUTC.Location())
+	firstDayOfNextMonth := time.Date(year, month+1, 1, 0, 0, 0, 0, asOfInUTC.Location())
+	// The last month may be shorter than the elapsed days of this month (e.g. "Mar 1-30" vs "Feb").
+	// In that case, the whole last month is the same period.
+	sameDayLastMonth := firstDayOfLastMonth.AddDate(0, 0, day-1)
+	if sameDayLastMonth.After(firstDayOfThisMonth) {
+		sameDayLastMonth = firstDayOfThisMonth
+	}
 
 	dateFmt := "2006-01-02" // Use the same format as the AWS API response, "types.ResultByTime.TimePeriod.Start/End".
 	opt.dates.asOf = asOfInUTC.Format(dateFmt)
+	opt.dates.yesterday = yesterday.Format(dateFmt)
 	opt.dates.oneWeekAgo = oneWeekAgo.Format(dateFmt)
+	opt.dates.twoWeeksAgo = twoWeeksAgo.Format(dateFmt)
 	opt.dates.firstDayOfThisMonth = firstDayOfThisMonth.Format(dateFmt)
 	opt.dates.firstDayOfLastMonth = firstDayOfLastMonth.Format(dateFmt)
+	opt.dates.sameDayLastMonth = sameDayLastMonth.Format(dateFmt)
+	opt.dates.firstDayOfNextMonth = firstDayOfNextMonth.Format(dateFmt)
 	return opt
 }
 
+// AsOf returns the date which the costs are retrieved as of.
+func (opt AcosGetCostsOption) AsOf() time.Time {
+	t, _ := time.Parse("2006-01-02", opt.dates.asOf)
+	return t
+}
+
+// NewClosedMonthOption returns the option to retrieve the costs of the closed month, which contains the given date.
+// The costs are retrieved with the MONTHLY granularity as of the first day of the next month,
+// so that Cost.AmountLastMonth is the cost of the month, Cost.Daily is empty, and the other amounts are zero.
+// It makes far fewer API requests than the daily costs, e.g. to retrieve the costs of many past months.
+func NewClosedMonthOption(month time.Time) AcosGetCostsOption {
+	year, m, _ := month.Date()
+	opt := NewGetCostsOption(time.Date(year, m+1, 1, 0, 0, 0, 0, time.UTC))
+	opt.monthly = true
+	return opt
+}
+
+// days returns the dates of the days which GetCosts retrieves, from the first day of the last month until the day before "asOf".
+func (opt AcosGetCostsOption) days() []string {
+	if opt.monthly {
+		return nil
+	}
+	dateFmt := "2006-01-02"
+	start, err1 := time.Parse(dateFmt, opt.dates.firstDayOfLastMonth)
+	end, err2 := time.Parse(dateFmt, opt.dates.asOf)
+	if err1 != nil || err2 != nil {
+		return nil
+	}
+	days := []string{}
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format(dateFmt))
+	}
+	return days
+}
+
 // Cost represents a cost for a given account.
+//
+// The "*ChangePercent" fields are nil when there is nothing to compare with,
+// e.g. the previous period cost is zero.
 type Cost struct {
-	AccountID                string
-	AccountName              string
-	LatestDailyCostIncrease  float64
-	LatestWeeklyCostIncrease float64
-	AmountLastMonth          float64
-	AmountThisMonth          float64
+	AccountID                  string
+	AccountName                string
+	LatestDailyCostIncrease    float64
+	LatestWeeklyCostIncrease   float64
+	AmountLastMonth            float64
+	AmountThisMonth            float64
+	AmountLastMonthSamePeriod  float64    // The cost of the last month for the same number of days as this month-to-date.
+	PreviousDailyCostIncrease  float64    // The cost of the day before yesterday.
+	PreviousWeeklyCostIncrease float64    // The cost of the week before last week.
+	MonthToDateChangePercent   *float64   // This month vs the same period of the last month.
+	DailyChangePercent         *float64   // Yesterday vs the day before yesterday.
+	WeeklyChangePercent        *float64   // Last week vs the week before last week.
+	ForecastThisMonth          *float64   // The forecasted cost of this month. See AcosGetCostsOption.IncludeForecast.
+	AccountStatus              string     `json:",omitempty"` // The status of the account in AWS Organizations, e.g. "ACTIVE" and "SUSPENDED".
+	JoinedTimestamp            *time.Time `json:",omitempty"` // The date and time when the account joined the organization.
+	// JoinedThisMonth is true when the account joined the organization in the month of "asOf",
+	// which means the cost of the last month is expected to be partial or missing.
+	JoinedThisMonth bool `json:",omitempty"`
+	// Daily represents the daily costs from the first day of the last month until the day before "asOf".
+	// It's omitted in JSON to keep it small. See CostMatrix for the JSON-friendly representation.
+	Daily []DailyCost `json:"-"`
+}
+
+// DailyCost represents a cost of a day.
+type DailyCost struct {
+	Date   string // The format is "YYYY-MM-DD".
+	Amount float64
+}
+
+// computeChanges fills the "*ChangePercent" fields using the amount fields.
+func (c *Cost) computeChanges() {
+	c.MonthToDateChangePercent = percentChange(c.AmountThisMonth, c.AmountLastMonthSamePeriod)
+	c.DailyChangePercent = percentChange(c.LatestDailyCostIncrease, c.PreviousDailyCostIncrease)
+	c.WeeklyChangePercent = percentChange(c.LatestWeeklyCostIncrease, c.PreviousWeeklyCostIncrease)
+}
+
+// percentChange returns the change from `previous` to `current` in percent.
+// It returns nil when `previous` is zero.
+func percentChange(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	p := (current - previous) / math.Abs(previous) * 100
+	return &p
 }
 
 // Costs represents a map of Cost. The map key is the account ID of the respective Cost.
 type Costs map[string]Cost // map[accountId]Cost
 
+// Total returns the sum of all the costs as a Cost. The account ID and name of the returned Cost are empty.
+func (c Costs) Total() Cost {
+	total := Cost{}
+	for _, v := range c {
+		total.LatestDailyCostIncrease += v.LatestDailyCostIncrease
+		total.LatestWeeklyCostIncrease += v.LatestWeeklyCostIncrease
+		total.AmountLastMonth += v.AmountLastMonth
+		total.AmountThisMonth += v.AmountThisMonth
+		total.AmountLastMonthSamePeriod += v.AmountLastMonthSamePeriod
+		total.PreviousDailyCostIncrease += v.PreviousDailyCostIncrease
+		total.PreviousWeeklyCostIncrease += v.PreviousWeeklyCostIncrease
+		if v.ForecastThisMonth != nil {
+			f := *v.ForecastThisMonth
+			if total.ForecastThisMonth != nil {
+				f += *total.ForecastThisMonth
+			}
+			total.ForecastThisMonth = &f
+		}
+	}
+	total.computeChanges()
+	return total
+}
+
 // Group wraps up AWS Organization Group struct.
 type Group types.Group
 
@@ -101,104 +246,259 @@ func (g *Group) getAmount() float64 {
 }
 
 // GetCosts returns the costs for given accounts.
-// It raises an error when the `accounts` arg doesn't contain any account.
+// It raises an error when the `accounts` arg doesn't contain any account, unless the AllLinkedAccounts option is set.
+// When it fails for some of the accounts, it returns the costs of the other accounts with a *PartialError.
+// The *PartialError also reports the accounts whose forecasts or names failed, which are returned without them.
 func GetCosts(ctx context.Context, accounts Accounts, opt AcosGetCostsOption) (Costs, error) {
 	accountIds := accounts.AccountIds()
-	if len(accountIds) == 0 {
-		return nil, fmt.Errorf("error no account to retrieve cost: GetCosts requires at least one account in Accounts")
+	if len(accountIds) == 0 && !opt.AllLinkedAccounts {
+		return nil, fmt.Errorf("%w to retrieve cost: GetCosts requires at least one account in Accounts", ErrNoAccounts)
+	}
+	if opt.AllLinkedAccounts {
+		// No filter by accounts, to include the accounts which are not in the given accounts.
+		accountIds = nil
 	}
-	ceOpt := acosOptToCostExplorerOpt(opt, accountIds)
 
 	// The following GetCostAndUsage API won't return any result in some cases (e.g. when the account is newly created).
 	// We create and fill the result map with the account IDs and names first, and then fill the amount later,
 	// to make sure the result map always contains all the accounts.
+	days := opt.days()
 	costs := make(map[string]Cost)
 	for _, a := range accounts {
-		costs[*a.Id] = Cost{
-			AccountID:                *a.Id,
-			AccountName:              *a.Name,
-			LatestDailyCostIncrease:  0,
-			LatestWeeklyCostIncrease: 0,
-			AmountLastMonth:          0,
-			AmountThisMonth:          0,
-		}
+		c := newCost(*a.Id, *a.Name, days)
+		c.setLifecycle(a, opt)
+		costs[*a.Id] = c
 	}
 
-	var nextToken *string
-	for {
-		ceOpt.NextPageToken = nextToken
-
-		out, err := ceClient.GetCostAndUsage(ctx, &ceOpt)
-		if err != nil {
-			return nil, err
+	batches, results, errs := getCostAndUsageInBatches(ctx, accountIds, func(batch []string) costexplorer.GetCostAndUsageInput {
+		return acosOptToCostExplorerOpt(opt, batch)
+	})
+	failures := make(map[string]error)
+	var firstErr error
+	for i, err := range errs {
+		if err == nil {
+			continue
 		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		for _, id := range batches[i] {
+			failures[id] = err
+			delete(costs, id)
+		}
+	}
+	if firstErr != nil && (len(costs) == 0 || len(batches) == 1) {
+		// Nothing succeeded.
+		return nil, firstErr
+	}
 
-		thisMonth := false
-		lastWeek := false
-		for _, r := range out.ResultsByTime {
-
-			if *r.TimePeriod.Start == opt.dates.firstDayOfThisMonth {
-				// We assume that the AWS API returns sorted "out.ResultsByTime" slice items
-				// by "r.TimePeriod.Start".
-				// So we can assume that the rest of the items are also for this month, when
-				// the "r.TimePeriod.Start" equals to the "first day of this month".
-				//
-				// See the official doc for more details about the response data structure:
-				// https://docs.aws.amazon.com/aws-cost-management/latest/APIReference/API_GetCostAndUsage.html#API_GetCostAndUsage_ResponseSyntax
-				thisMonth = true
-			}
-			// Flag "lastWeek" if the first week has passed of this month.
-			if thisMonth && *r.TimePeriod.Start == opt.dates.oneWeekAgo {
-				lastWeek = true
-			}
-
+	// Merge the results in the order of the batches, so that the amounts are always summed up in the same order.
+	for _, batch := range results {
+		for _, r := range batch {
+			start, end := *r.TimePeriod.Start, *r.TimePeriod.End
 			for _, g := range r.Groups {
 				grp := Group(g)
 				accntId := grp.getAccountId()
-				amount := grp.getAmount()
-
-				c := costs[accntId]
-				if thisMonth {
-					c.AmountThisMonth += amount
-				} else {
-					c.AmountLastMonth += amount
+				c, ok := costs[accntId]
+				if !ok {
+					// Only happens with the AllLinkedAccounts option. The name is filled later.
+					c = newCost(accntId, "", days)
+					c.AccountStatus = AccountStatusNotInOrganization
 				}
+				c.add(start, end, grp.getAmount(), opt)
+				costs[accntId] = c
+			}
+		}
+	}
 
-				// Store yesterday's cost as the "latest daily cost increase".
-				//
-				// The types.ResultByTime item, that represents yesterday's cost, should has
-				// today's date in "r.TimePeriod.End", and yesterday's date in "r.TimePeriod.Start".
-				// We only check the "r.TimePeriod.End" value here, because we we called the AWS API
-				// with the "DAILY" granularity.
-				if *r.TimePeriod.End == opt.dates.asOf {
-					if opt.dates.asOf != opt.dates.firstDayOfThisMonth { // Unless today is the first day of month.
-						c.LatestDailyCostIncrease = amount
-					}
-				}
+	for id, err := range fillUnknownAccountNames(ctx, costs, opt) {
+		failures[id] = err
+	}
+	for id, c := range costs {
+		c.computeChanges()
+		costs[id] = c
+	}
+	if opt.IncludeForecast {
+		for id, err := range fillForecasts(ctx, costs, opt) {
+			failures[id] = err
+		}
+	}
+	if len(failures) > 0 {
+		return costs, &PartialError{Failures: failures}
+	}
+	return costs, nil
+}
 
-				// Add the cost onto the "latest weekly cost increase".
-				if lastWeek {
-					c.LatestWeeklyCostIncrease += amount
-				}
+// PartialError is returned with the costs of the succeeded accounts, when retrieving the costs failed for some accounts.
+type PartialError struct {
+	Failures map[string]error // The errors of the failed accounts. The map key is the account ID.
+}
 
-				costs[accntId] = c
-			}
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("error failed to retrieve the costs of %d accounts", len(e.Failures))
+}
+
+// Unwrap returns the errors of the failed accounts in the order of the account IDs.
+func (e *PartialError) Unwrap() []error {
+	ids := make([]string, 0, len(e.Failures))
+	for id := range e.Failures {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		errs[i] = e.Failures[id]
+	}
+	return errs
+}
+
+// newCost returns a Cost with the zero-filled daily costs of the given days.
+func newCost(accountId, accountName string, days []string) Cost {
+	daily := make([]DailyCost, len(days))
+	for i, d := range days {
+		daily[i] = DailyCost{Date: d, Amount: 0}
+	}
+	return Cost{
+		AccountID:                accountId,
+		AccountName:              accountName,
+		LatestDailyCostIncrease:  0,
+		LatestWeeklyCostIncrease: 0,
+		AmountLastMonth:          0,
+		AmountThisMonth:          0,
+		Daily:                    daily,
+	}
+}
+
+// setLifecycle fills the fields of the account status and when the account joined the organization.
+func (c *Cost) setLifecycle(a Account, opt AcosGetCostsOption) {
+	c.AccountStatus = string(a.Status)
+	c.JoinedTimestamp = a.JoinedTimestamp
+	if a.JoinedTimestamp != nil {
+		joined := a.JoinedTimestamp.UTC().Format("2006-01-02")
+		c.JoinedThisMonth = joined >= opt.dates.firstDayOfThisMonth && joined <= opt.dates.asOf
+	}
+}
+
+// add adds the daily `amount` of the "start" - "end" period onto the respective amount fields.
+//
+// We compare the dates as strings, because they are in the "YYYY-MM-DD" format.
+// We only check either one of "start" or "end" in most cases, because we called the AWS API
+// with the "DAILY" granularity. See the official doc for more details about the response data structure:
+// https://docs.aws.amazon.com/aws-cost-management/latest/APIReference/API_GetCostAndUsage.html#API_GetCostAndUsage_ResponseSyntax
+func (c *Cost) add(start, end string, amount float64, opt AcosGetCostsOption) {
+	// The daily costs are zero-filled and sorted by date, so that we can find the day by the distance from the first day.
+	if len(c.Daily) > 0 {
+		if i := daysBetween(c.Daily[0].Date, start); i >= 0 && i < len(c.Daily) && c.Daily[i].Date == start {
+			c.Daily[i].Amount += amount
 		}
+	}
+
+	d := opt.dates
+	if start >= d.firstDayOfThisMonth {
+		c.AmountThisMonth += amount
+	} else {
+		c.AmountLastMonth += amount
+		if start < d.sameDayLastMonth {
+			c.AmountLastMonthSamePeriod += amount
+		}
+	}
+
+	// Store yesterday's cost as the "latest daily cost increase", and the day before yesterday's cost
+	// as the "previous daily cost increase", unless today is the first day of month.
+	if d.asOf != d.firstDayOfThisMonth {
+		if end == d.asOf {
+			c.LatestDailyCostIncrease = amount
+		} else if end == d.yesterday {
+			c.PreviousDailyCostIncrease = amount
+		}
+	}
+
+	// Add the cost onto the "latest weekly cost increase" if the first week has passed of this month,
+	// and the week before onto the "previous weekly cost increase" to compare with.
+	if d.oneWeekAgo >= d.firstDayOfThisMonth {
+		if start >= d.oneWeekAgo {
+			c.LatestWeeklyCostIncrease += amount
+		} else if start >= d.twoWeeksAgo {
+			c.PreviousWeeklyCostIncrease += amount
+		}
+	}
+}
+
+// daysBetween returns the number of days from `from` to `to`. The format of both dates is "YYYY-MM-DD".
+// It returns -1 when either one of the dates is invalid.
+func daysBetween(from, to string) int {
+	f, err1 := time.Parse("2006-01-02", from)
+	t, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil {
+		return -1
+	}
+	return int(t.Sub(f).Hours() / 24)
+}
 
+// getCostAndUsageInBatches calls the GetCostAndUsage API with the input of each batch of the account IDs concurrently.
+// It returns the batches, and the results and the error of each batch in the order of the batches. The account IDs
+// are sorted before being split so that the batches are deterministic. Empty `accountIds` means all the linked
+// accounts in a single batch. A failed batch doesn't stop the others.
+func getCostAndUsageInBatches(ctx context.Context, accountIds []string, input func(batch []string) costexplorer.GetCostAndUsageInput) ([][]string, [][]types.ResultByTime, []error) {
+	batches := splitAccountIds(accountIds, ceAccountBatchSize)
+	results := make([][]types.ResultByTime, len(batches))
+	errs := make([]error, len(batches))
+
+	sem := make(chan struct{}, ceBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(i int, batch []string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = getCostAndUsage(ctx, input(batch))
+		}(i, batch)
+	}
+	wg.Wait()
+	return batches, results, errs
+}
+
+// getCostAndUsage calls the GetCostAndUsage API with the input, and returns the results of all the pages.
+func getCostAndUsage(ctx context.Context, ceOpt costexplorer.GetCostAndUsageInput) ([]types.ResultByTime, error) {
+	var results []types.ResultByTime
+	var nextToken *string
+	for {
+		ceOpt.NextPageToken = nextToken
+		out, err := ceClient.GetCostAndUsage(ctx, &ceOpt)
+		if err != nil {
+			return nil, classifyError("ce:GetCostAndUsage", err)
+		}
+		results = append(results, out.ResultsByTime...)
 		nextToken = out.NextPageToken
 		if nextToken == nil {
-			break
+			return results, nil
 		}
 	}
+}
 
-	return costs, nil
+// splitAccountIds returns the sorted account IDs split into batches of the size.
+// It returns a single nil batch when `accountIds` is empty.
+func splitAccountIds(accountIds []string, size int) [][]string {
+	if len(accountIds) == 0 {
+		return [][]string{nil}
+	}
+	sorted := make([]string, len(accountIds))
+	copy(sorted, accountIds)
+	sort.Strings(sorted)
+	var batches [][]string
+	for len(sorted) > size {
+		batches = append(batches, sorted[:size])
+		sorted = sorted[size:]
+	}
+	return append(batches, sorted)
 }
 
 // acosOptToCostExplorerOpt returns the AWS Cost Explorer's GetCostAndUsageInput param built from the acos options.
 func acosOptToCostExplorerOpt(opt AcosGetCostsOption, accountIds []string) costexplorer.GetCostAndUsageInput {
 	// Base input parameter
 	in := costexplorer.GetCostAndUsageInput{
-		Granularity: ceDataGranularity,
+		Granularity: ceGranularity(opt),
 		Metrics:     []string{ceCostMetric},
 		TimePeriod: &types.DateInterval{
 			// Get the cost for the last month and this month
@@ -211,16 +511,31 @@ func acosOptToCostExplorerOpt(opt AcosGetCostsOption, accountIds []string) coste
 				Key:  aws.String(ceCostGroupBy),
 			},
 		},
-		Filter: &types.Expression{
-			And: []types.Expression{
-				{
-					Dimensions: &types.DimensionValues{
-						Key:    ceCostGroupBy,
-						Values: accountIds,
-					},
-				},
+		Filter: acosOptToFilter(opt, accountIds),
+	}
+	return in
+}
+
+// ceGranularity returns the granularity of the GetCostAndUsage API for the acos options.
+func ceGranularity(opt AcosGetCostsOption) types.Granularity {
+	if opt.monthly {
+		return types.GranularityMonthly
+	}
+	return ceDataGranularity
+}
+
+// acosOptToFilter returns the AWS Cost Explorer's filter expression built from the acos options.
+// It doesn't filter by accounts when `accountIds` is empty, and it returns nil when there's nothing to filter.
+// The `extra` expressions, e.g. the service filter of GetCostBreakdown, are combined with the others by "And".
+func acosOptToFilter(opt AcosGetCostsOption, accountIds []string, extra ...types.Expression) *types.Expression {
+	filter := &types.Expression{}
+	if len(accountIds) > 0 {
+		filter.And = append(filter.And, types.Expression{
+			Dimensions: &types.DimensionValues{
+				Key:    ceCostGroupBy,
+				Values: accountIds,
 			},
-		},
+		})
 	}
 
 	// Exclude options
@@ -237,8 +552,15 @@ func acosOptToCostExplorerOpt(opt AcosGetCostsOption, accountIds []string) coste
 	if opt.ExcludeSupport {
 		v = append(v, "Support")
 	}
-	if len(v) > 0 {
-		in.Filter.And = append(in.Filter.And, types.Expression{
+	if opt.UsageOnly {
+		filter.And = append(filter.And, types.Expression{
+			Dimensions: &types.DimensionValues{
+				Key:    "RECORD_TYPE",
+				Values: usageRecordTypes,
+			},
+		})
+	} else if len(v) > 0 {
+		filter.And = append(filter.And, types.Expression{
 			Not: &types.Expression{
 				Dimensions: &types.DimensionValues{
 					Key:    "RECORD_TYPE",
@@ -247,6 +569,14 @@ func acosOptToCostExplorerOpt(opt AcosGetCostsOption, accountIds []string) coste
 			},
 		})
 	}
-
-	return in
+	filter.And = append(filter.And, extra...)
+
+	// The "And" expression requires at least two expressions.
+	switch len(filter.And) {
+	case 0:
+		return nil
+	case 1:
+		return &filter.And[0]
+	}
+	return filter
 }
@@ -2,7 +2,11 @@ package acos
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -53,7 +57,18 @@ func (m mockGetCostAndUsageAPI) GetCostAndUsage(ctx context.Context, params *cos
 	return m(ctx, params, optFns...)
 }
 
+// zeroDailyCosts returns the zero-filled daily costs for the days which GetCosts retrieves.
+func zeroDailyCosts(opt AcosGetCostsOption) []DailyCost {
+	daily := []DailyCost{}
+	for _, d := range opt.days() {
+		daily = append(daily, DailyCost{Date: d, Amount: 0})
+	}
+	return daily
+}
+
 func TestWithMock_GetCosts(t *testing.T) {
+	opt := NewGetCostsOption(time.Now().UTC())
+
 	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
 		t.Helper()
 		out := &costexplorer.GetCostAndUsageOutput{
@@ -85,7 +100,7 @@ func TestWithMock_GetCosts(t *testing.T) {
 						Name: toPointer("test"),
 					},
 				},
-				opt: NewGetCostsOption(time.Now().UTC()),
+				opt: opt,
 			},
 			want: Costs{
 				"123456789012": Cost{
@@ -95,6 +110,7 @@ func TestWithMock_GetCosts(t *testing.T) {
 					LatestWeeklyCostIncrease: 0,
 					AmountLastMonth:          0,
 					AmountThisMonth:          0,
+					Daily:                    zeroDailyCosts(opt),
 				},
 			},
 			wantErr: false,
@@ -113,3 +129,283 @@ func TestWithMock_GetCosts(t *testing.T) {
 		})
 	}
 }
+
+// dailyResults returns the DAILY granularity results of GetCostAndUsage API for an account from `start` until `end` (exclusive).
+func dailyResults(accountId string, start, end time.Time, amount func(day time.Time) float64) []types.ResultByTime {
+	results := []types.ResultByTime{}
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		results = append(results, types.ResultByTime{
+			TimePeriod: &types.DateInterval{
+				Start: toPointer(d.Format("2006-01-02")),
+				End:   toPointer(d.AddDate(0, 0, 1).Format("2006-01-02")),
+			},
+			Groups: []types.Group{
+				{
+					Keys: []string{accountId},
+					Metrics: map[string]types.MetricValue{
+						ceCostMetric: {Amount: toPointer(strconv.FormatFloat(amount(d), 'f', -1, 64))},
+					},
+				},
+			},
+		})
+	}
+	return results
+}
+
+func TestWithMock_GetCosts_changes(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		t.Helper()
+		// $1 per day in June, and $N on July N.
+		results := dailyResults("123456789012", time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), asOf, func(day time.Time) float64 {
+			if day.Month() == time.June {
+				return 1
+			}
+			return float64(day.Day())
+		})
+		return &costexplorer.GetCostAndUsageOutput{ResultsByTime: results}, nil
+	})
+
+	got, err := GetCosts(context.Background(), Accounts{
+		"123456789012": Account{
+			Id:   toPointer("123456789012"),
+			Name: toPointer("test"),
+		},
+	}, NewGetCostsOption(asOf))
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	c := got["123456789012"]
+	for _, tt := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"AmountThisMonth", c.AmountThisMonth, 153},
+		{"AmountLastMonth", c.AmountLastMonth, 30},
+		{"AmountLastMonthSamePeriod", c.AmountLastMonthSamePeriod, 17},
+		{"LatestDailyCostIncrease", c.LatestDailyCostIncrease, 17},
+		{"PreviousDailyCostIncrease", c.PreviousDailyCostIncrease, 16},
+		{"LatestWeeklyCostIncrease", c.LatestWeeklyCostIncrease, 98},
+		{"PreviousWeeklyCostIncrease", c.PreviousWeeklyCostIncrease, 49},
+		{"MonthToDateChangePercent", *c.MonthToDateChangePercent, 800},
+		{"DailyChangePercent", *c.DailyChangePercent, 6.25},
+		{"WeeklyChangePercent", *c.WeeklyChangePercent, 100},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("GetCosts() %s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+type mockGetCostForecastAPI func(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error)
+
+func (m mockGetCostForecastAPI) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	return m(ctx, params, optFns...)
+}
+
+func TestWithMock_GetCosts_forecast(t *testing.T) {
+	asOf := time.Now().UTC()
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		t.Helper()
+		firstDayOfThisMonth := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return &costexplorer.GetCostAndUsageOutput{
+			ResultsByTime: dailyResults("123456789012", firstDayOfThisMonth, asOf.Truncate(24*time.Hour), func(day time.Time) float64 { return 1 }),
+		}, nil
+	})
+	ceForecastClient = mockGetCostForecastAPI(func(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+		t.Helper()
+		if params.Filter.And[0].Dimensions.Values[0] == "210987654321" {
+			return nil, &types.DataUnavailableException{}
+		}
+		return &costexplorer.GetCostForecastOutput{Total: &types.MetricValue{Amount: toPointer("100")}}, nil
+	})
+
+	opt := NewGetCostsOption(asOf)
+	opt.IncludeForecast = true
+	got, err := GetCosts(context.Background(), Accounts{
+		"123456789012": Account{Id: toPointer("123456789012"), Name: toPointer("test")},
+		"210987654321": Account{Id: toPointer("210987654321"), Name: toPointer("new")},
+	}, opt)
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	want := float64(asOf.Day()-1) + 100 // The actual cost of this month plus the forecast of the rest.
+	if f := got["123456789012"].ForecastThisMonth; f == nil || *f != want {
+		t.Errorf("GetCosts() ForecastThisMonth = %v, want %v", f, want)
+	}
+	if f := got["210987654321"].ForecastThisMonth; f != nil {
+		t.Errorf("GetCosts() ForecastThisMonth = %v, want nil", *f)
+	}
+}
+
+func TestCost_setLifecycle(t *testing.T) {
+	opt := NewGetCostsOption(time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC))
+	joined := func(s string) *time.Time {
+		ts, _ := time.Parse(time.RFC3339, s)
+		return &ts
+	}
+	tests := []struct {
+		name       string
+		account    Account
+		wantStatus string
+		wantNew    bool
+	}{
+		{name: "joined this month", account: Account{Status: "ACTIVE", JoinedTimestamp: joined("2023-07-03T10:00:00Z")}, wantStatus: "ACTIVE", wantNew: true},
+		{name: "joined last month", account: Account{Status: "SUSPENDED", JoinedTimestamp: joined("2023-06-30T23:59:59Z")}, wantStatus: "SUSPENDED"},
+		{name: "joined after asOf", account: Account{Status: "ACTIVE", JoinedTimestamp: joined("2023-07-20T00:00:00Z")}, wantStatus: "ACTIVE"},
+		{name: "not in an organization", account: Account{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Cost{}
+			c.setLifecycle(tt.account, opt)
+			if c.AccountStatus != tt.wantStatus || c.JoinedThisMonth != tt.wantNew {
+				t.Errorf("setLifecycle() = %v, %v, want %v, %v", c.AccountStatus, c.JoinedThisMonth, tt.wantStatus, tt.wantNew)
+			}
+		})
+	}
+}
+
+// filterAccountIds returns the account IDs in the LINKED_ACCOUNT filter of the GetCostAndUsage request.
+func filterAccountIds(in *costexplorer.GetCostAndUsageInput) []string {
+	if in.Filter == nil {
+		return nil
+	}
+	exprs := append([]types.Expression{*in.Filter}, in.Filter.And...)
+	for _, e := range exprs {
+		if e.Dimensions != nil && e.Dimensions.Key == ceCostGroupBy {
+			return e.Dimensions.Values
+		}
+	}
+	return nil
+}
+
+func TestWithMock_GetCosts_batches(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	const numAccounts = 5000
+	accounts := make(Accounts, numAccounts)
+	for i := 0; i < numAccounts; i++ {
+		id := fmt.Sprintf("%012d", i+1)
+		accounts[id] = Account{Id: toPointer(id), Name: toPointer("account-" + id)}
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight, calls := 0, 0, 0
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		mu.Lock()
+		inFlight++
+		calls++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		time.Sleep(time.Millisecond) // Let the batches overlap.
+
+		ids := filterAccountIds(params)
+		if len(ids) == 0 || len(ids) > ceAccountBatchSize {
+			t.Errorf("GetCostAndUsage() called with %d account IDs, want 1 to %d", len(ids), ceAccountBatchSize)
+		}
+		// Two pages per batch. The first page has the first half of the accounts.
+		half := len(ids) / 2
+		page, next := ids[:half], toPointer("next")
+		if params.NextPageToken != nil {
+			page, next = ids[half:], nil
+		}
+		var results []types.ResultByTime
+		for _, id := range page {
+			amount, _ := strconv.Atoi(id)
+			results = append(results, dailyResults(id, asOf.AddDate(0, 0, -1), asOf, func(time.Time) float64 { return float64(amount) })...)
+		}
+		return &costexplorer.GetCostAndUsageOutput{ResultsByTime: results, NextPageToken: next}, nil
+	})
+
+	got, err := GetCosts(context.Background(), accounts, NewGetCostsOption(asOf))
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	if len(got) != numAccounts {
+		t.Errorf("GetCosts() returned %d accounts, want %d", len(got), numAccounts)
+	}
+	for id, c := range got {
+		want, _ := strconv.Atoi(id)
+		if c.AmountThisMonth != float64(want) || c.AccountName != "account-"+id {
+			t.Errorf("GetCosts()[%s] = %v (%s), want %v", id, c.AmountThisMonth, c.AccountName, want)
+			break
+		}
+	}
+	if wantCalls := numAccounts / ceAccountBatchSize * 2; calls != wantCalls {
+		t.Errorf("GetCostAndUsage() called %d times, want %d", calls, wantCalls)
+	}
+	if maxInFlight > ceBatchConcurrency {
+		t.Errorf("GetCostAndUsage() called concurrently %d times, want at most %d", maxInFlight, ceBatchConcurrency)
+	}
+
+	again, err := GetCosts(context.Background(), accounts, NewGetCostsOption(asOf))
+	if err != nil || !reflect.DeepEqual(got, again) {
+		t.Errorf("GetCosts() is not deterministic")
+	}
+}
+
+func TestWithMock_GetCosts_batchError(t *testing.T) {
+	accounts := make(Accounts)
+	for i := 0; i < ceAccountBatchSize*3; i++ {
+		id := fmt.Sprintf("%012d", i+1)
+		accounts[id] = Account{Id: toPointer(id), Name: toPointer(id)}
+	}
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		if filterAccountIds(params)[0] == fmt.Sprintf("%012d", ceAccountBatchSize+1) {
+			return nil, &types.LimitExceededException{}
+		}
+		return &costexplorer.GetCostAndUsageOutput{}, nil
+	})
+	got, err := GetCosts(context.Background(), accounts, NewGetCostsOption(time.Now().UTC()))
+	var partialErr *PartialError
+	var limitErr *types.LimitExceededException
+	if !errors.As(err, &partialErr) || !errors.As(err, &limitErr) {
+		t.Fatalf("GetCosts() error = %v, want PartialError of LimitExceededException", err)
+	}
+	if len(got) != ceAccountBatchSize*2 || len(partialErr.Failures) != ceAccountBatchSize {
+		t.Errorf("GetCosts() = %d costs and %d failures, want %d and %d", len(got), len(partialErr.Failures), ceAccountBatchSize*2, ceAccountBatchSize)
+	}
+	if _, ok := partialErr.Failures[fmt.Sprintf("%012d", ceAccountBatchSize+1)]; !ok {
+		t.Errorf("GetCosts() failures don't contain the accounts of the failed batch")
+	}
+
+	// No costs when all the batches fail.
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		return nil, &types.LimitExceededException{}
+	})
+	if got, err := GetCosts(context.Background(), accounts, NewGetCostsOption(time.Now().UTC())); got != nil || !errors.As(err, &limitErr) || errors.As(err, &partialErr) {
+		t.Errorf("GetCosts() = %v, %v, want nil and LimitExceededException", got, err)
+	}
+}
+
+func TestWithMock_GetCosts_closedMonth(t *testing.T) {
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		if params.Granularity != types.GranularityMonthly || *params.TimePeriod.Start != "2023-06-01" || *params.TimePeriod.End != "2023-07-01" {
+			t.Errorf("GetCostAndUsage() params = %s %s - %s, want MONTHLY 2023-06-01 - 2023-07-01", params.Granularity, *params.TimePeriod.Start, *params.TimePeriod.End)
+		}
+		// A single result of the month.
+		return &costexplorer.GetCostAndUsageOutput{
+			ResultsByTime: dailyResults("123456789012", time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 6, 2, 0, 0, 0, 0, time.UTC), func(day time.Time) float64 { return 300 }),
+		}, nil
+	})
+	accounts := Accounts{"123456789012": Account{Id: toPointer("123456789012"), Name: toPointer("test")}}
+	costs, err := GetCosts(context.Background(), accounts, NewClosedMonthOption(time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	c := costs["123456789012"]
+	if c.AmountLastMonth != 300 || c.AmountThisMonth != 0 || c.AmountLastMonthSamePeriod != 0 || c.LatestDailyCostIncrease != 0 || len(c.Daily) != 0 {
+		t.Errorf("GetCosts() = %+v, want only AmountLastMonth = 300", c)
+	}
+	if got := PlanGetCosts(accounts, NewClosedMonthOption(time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC))).Requests(); got != 1 {
+		t.Errorf("PlanGetCosts().Requests() = %d, want 1", got)
+	}
+}
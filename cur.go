@@ -0,0 +1,386 @@
+package acos
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+// The columns of the Cost and Usage Report. The legacy CUR columns such as "lineItem/UsageAccountId"
+// are normalized to the CUR 2.0 columns such as "line_item_usage_account_id".
+const (
+	curPayerId      = "bill_payer_account_id"
+	curAccountId    = "line_item_usage_account_id"
+	curAccountName  = "line_item_usage_account_name"
+	curStartDate    = "line_item_usage_start_date"
+	curLineItemType = "line_item_line_item_type"
+	curProductCode  = "line_item_product_code"
+	curUsageType    = "line_item_usage_type"
+	curCost         = "line_item_unblended_cost"
+)
+
+// curServiceColumns are the columns of the service name, in the order of preference.
+var curServiceColumns = []string{"product_product_name", "product_servicename", curProductCode}
+
+// LoadCurFiles reads the line items of the Cost and Usage Report (CUR 2.0 or legacy) in the CSV format, optionally
+// gzipped, or in the Parquet format. The path is either a file or a directory, which is read recursively for
+// the ".csv", ".csv.gz" and ".parquet" files.
+//
+// The line items are summed up by the account, the usage start date and the dimensions of LineItem while reading,
+// so the memory usage depends on the number of the usage types rather than the number of the line items.
+//
+// The RECORD_TYPE dimension is derived from the line item types, e.g. the "Fee" line items are "Support" for the AWS Support
+// plans and "Upfront" otherwise. The SERVICE dimension is the product name when the report has it, or the product code.
+func LoadCurFiles(path string) (*LineItemSource, error) {
+	var files []string
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := strings.ToLower(d.Name())
+		if !d.IsDir() && (strings.HasSuffix(name, ".csv") || strings.HasSuffix(name, ".csv.gz") || strings.HasSuffix(name, ".parquet")) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("error no Cost and Usage Report files found in %s", path)
+	}
+	items := newLineItemAggregator()
+	for _, f := range files {
+		if err := readCurFile(f, items.add); err != nil {
+			return nil, err
+		}
+	}
+	return NewLineItemSource(items.items), nil
+}
+
+// lineItemKey is the key to sum up the line items.
+type lineItemKey struct {
+	accountId, date, recordType, service, usageType string
+}
+
+// lineItemAggregator sums up the amounts of the line items of the same account, date and dimensions.
+type lineItemAggregator struct {
+	items []LineItem
+	index map[lineItemKey]int
+}
+
+func newLineItemAggregator() *lineItemAggregator {
+	return &lineItemAggregator{index: make(map[lineItemKey]int)}
+}
+
+func (a *lineItemAggregator) add(li LineItem) {
+	k := lineItemKey{li.AccountID, li.Date, li.RecordType, li.Service, li.UsageType}
+	if i, ok := a.index[k]; ok {
+		a.items[i].Amount += li.Amount
+		if len(a.items[i].AccountName) == 0 {
+			a.items[i].AccountName = strings.Clone(li.AccountName)
+		}
+		if len(a.items[i].PayerAccountID) == 0 {
+			a.items[i].PayerAccountID = strings.Clone(li.PayerAccountID)
+		}
+		return
+	}
+	// Clone the strings not to retain the whole records of the CSV reader.
+	k = lineItemKey{strings.Clone(k.accountId), strings.Clone(k.date), strings.Clone(k.recordType), strings.Clone(k.service), strings.Clone(k.usageType)}
+	a.index[k] = len(a.items)
+	a.items = append(a.items, LineItem{
+		AccountID:      k.accountId,
+		AccountName:    strings.Clone(li.AccountName),
+		Date:           k.date,
+		RecordType:     k.recordType,
+		Service:        k.service,
+		UsageType:      k.usageType,
+		Amount:         li.Amount,
+		PayerAccountID: strings.Clone(li.PayerAccountID),
+	})
+}
+
+func readCurFile(path string, add func(LineItem)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if strings.HasSuffix(strings.ToLower(path), ".parquet") {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if err := readCurParquet(f, info.Size(), add); err != nil {
+			return fmt.Errorf("error failed to read %s: %w", path, err)
+		}
+		return nil
+	}
+	var r io.Reader = f
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("error failed to read %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if err := readCur(r, add); err != nil {
+		return fmt.Errorf("error failed to read %s: %w", path, err)
+	}
+	return nil
+}
+
+// curColumns maps the columns of a Cost and Usage Report file to the indexes of its records.
+type curColumns struct {
+	index   map[string]int
+	service string // The column of the service name.
+}
+
+// newCurColumns returns the columns of the header, or an error when the required columns are missing.
+func newCurColumns(header []string) (*curColumns, error) {
+	c := &curColumns{index: make(map[string]int, len(header))}
+	for i, h := range header {
+		if len(h) > 0 {
+			c.index[normalizeCurColumn(h)] = i
+		}
+	}
+	for _, col := range []string{curAccountId, curStartDate, curCost} {
+		if _, ok := c.index[col]; !ok {
+			return nil, fmt.Errorf("error the column '%s' is missing", col)
+		}
+	}
+	for _, col := range curServiceColumns {
+		if _, ok := c.index[col]; ok {
+			c.service = col
+			break
+		}
+	}
+	return c, nil
+}
+
+// used returns the indexes of the columns which the line items are read from.
+func (c *curColumns) used() []int {
+	var indexes []int
+	for _, col := range []string{curPayerId, curAccountId, curAccountName, curStartDate, curLineItemType, curProductCode, curUsageType, curCost, c.service} {
+		if i, ok := c.index[col]; ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (c *curColumns) get(record []string, column string) string {
+	if i, ok := c.index[column]; ok && i < len(record) {
+		return record[i]
+	}
+	return ""
+}
+
+// lineItem returns the line item of the record.
+func (c *curColumns) lineItem(record []string) (LineItem, error) {
+	date := c.get(record, curStartDate)
+	if len(date) < len("2006-01-02") {
+		return LineItem{}, fmt.Errorf("error invalid usage start date '%s'", date)
+	}
+	amount, err := strconv.ParseFloat(c.get(record, curCost), 64)
+	if err != nil {
+		return LineItem{}, fmt.Errorf("error invalid unblended cost '%s'", c.get(record, curCost))
+	}
+	return LineItem{
+		AccountID:      c.get(record, curAccountId),
+		AccountName:    c.get(record, curAccountName),
+		Date:           date[:len("2006-01-02")],
+		RecordType:     curRecordType(c.get(record, curLineItemType), c.get(record, curProductCode)),
+		Service:        c.get(record, c.service),
+		UsageType:      c.get(record, curUsageType),
+		Amount:         amount,
+		PayerAccountID: c.get(record, curPayerId),
+	}, nil
+}
+
+// readCur reads the line items of a CSV file of the Cost and Usage Report.
+func readCur(r io.Reader, add func(LineItem)) error {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	cols, err := newCurColumns(header)
+	if err != nil {
+		return err
+	}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		li, err := cols.lineItem(record)
+		if err != nil {
+			return fmt.Errorf("%w at line %d", err, line)
+		}
+		add(li)
+	}
+}
+
+// readCurParquet reads the line items of a Parquet file of the Cost and Usage Report.
+// The values of the columns are converted to the strings of the CSV format, e.g. the timestamps in RFC 3339.
+func readCurParquet(r io.ReaderAt, size int64, add func(LineItem)) error {
+	f, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return err
+	}
+	// The header has the top-level columns with a single value, e.g. not the "product" map of CUR 2.0,
+	// and the indexes of the records are the indexes of the leaf columns.
+	schema := f.Schema()
+	header := make([]string, len(schema.Columns()))
+	types := make([]parquet.Type, len(header))
+	for _, field := range schema.Fields() {
+		if !field.Leaf() || field.Repeated() {
+			continue
+		}
+		leaf, _ := schema.Lookup(field.Name())
+		header[leaf.ColumnIndex] = field.Name()
+		types[leaf.ColumnIndex] = field.Type()
+	}
+	cols, err := newCurColumns(header)
+	if err != nil {
+		return err
+	}
+	used := cols.used()
+
+	row := 1
+	record := make([]string, len(header))
+	values := make([]parquet.Value, len(header))
+	rows := make([]parquet.Row, 256)
+	for _, rg := range f.RowGroups() {
+		err := func() error {
+			rr := rg.Rows()
+			defer rr.Close()
+			for {
+				n, err := rr.ReadRows(rows)
+				for _, r := range rows[:n] {
+					for _, v := range r {
+						if c := v.Column(); c < len(values) {
+							values[c] = v
+						}
+					}
+					for _, c := range used {
+						record[c] = parquetValueString(values[c], types[c])
+					}
+					li, err := cols.lineItem(record)
+					if err != nil {
+						return fmt.Errorf("%w at row %d", err, row)
+					}
+					add(li)
+					row++
+				}
+				if errors.Is(err, io.EOF) {
+					return nil
+				} else if err != nil {
+					return err
+				}
+			}
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parquetValueString returns the string of the Parquet value in the CSV format of the Cost and Usage Report.
+func parquetValueString(v parquet.Value, t parquet.Type) string {
+	if v.IsNull() {
+		return ""
+	}
+	lt := t.LogicalType()
+	switch v.Kind() {
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return string(v.ByteArray())
+	case parquet.Int96:
+		return int96Time(v.Int96()).Format(time.RFC3339)
+	case parquet.Int64:
+		if lt != nil && lt.Timestamp != nil {
+			var ts time.Time
+			switch {
+			case lt.Timestamp.Unit.Millis != nil:
+				ts = time.UnixMilli(v.Int64())
+			case lt.Timestamp.Unit.Micros != nil:
+				ts = time.UnixMicro(v.Int64())
+			default:
+				ts = time.Unix(0, v.Int64())
+			}
+			return ts.UTC().Format(time.RFC3339)
+		}
+		return strconv.FormatInt(v.Int64(), 10)
+	case parquet.Int32:
+		if lt != nil && lt.Date != nil {
+			return time.Unix(int64(v.Int32())*24*60*60, 0).UTC().Format("2006-01-02")
+		}
+		return strconv.FormatInt(int64(v.Int32()), 10)
+	case parquet.Double:
+		return strconv.FormatFloat(v.Double(), 'f', -1, 64)
+	case parquet.Float:
+		return strconv.FormatFloat(float64(v.Float()), 'f', -1, 32)
+	}
+	return v.String()
+}
+
+// int96Time returns the time of the legacy INT96 timestamp, which is the nanoseconds of the day and the Julian day.
+func int96Time(i deprecated.Int96) time.Time {
+	const unixEpochJulianDay = 2440588
+	nanos := int64(i[1])<<32 | int64(i[0])
+	return time.Unix((int64(i[2])-unixEpochJulianDay)*24*60*60, nanos).UTC()
+}
+
+// normalizeCurColumn converts the legacy CUR column such as "lineItem/UsageAccountId" to the CUR 2.0 column
+// such as "line_item_usage_account_id". The CUR 2.0 columns are returned as is.
+func normalizeCurColumn(column string) string {
+	var b strings.Builder
+	prev := rune(0)
+	for _, r := range strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")) {
+		switch {
+		case r == '/':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+		prev = r
+	}
+	return b.String()
+}
+
+// curRecordType returns the RECORD_TYPE dimension value of Cost Explorer for the line item type.
+func curRecordType(lineItemType, productCode string) string {
+	switch lineItemType {
+	case "Fee":
+		if strings.HasPrefix(productCode, "AWS") && strings.Contains(productCode, "Support") {
+			return "Support"
+		}
+		return "Upfront"
+	case "RIFee":
+		return "Recurring reservation fee"
+	}
+	return lineItemType
+}
@@ -0,0 +1,205 @@
+package acos
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+// curCsv returns a CUR 2.0 CSV of $1 per day from 2023-06-01 until the day before 2023-07-18 for the account,
+// plus a credit and a support fee.
+func curCsv(accountId, accountName string) string {
+	var b strings.Builder
+	b.WriteString("\ufeffbill_payer_account_id,line_item_usage_account_id,line_item_usage_account_name,line_item_usage_start_date,line_item_line_item_type,line_item_product_code,line_item_usage_type,line_item_unblended_cost\n")
+	for d := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC); d.Before(time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)); d = d.AddDate(0, 0, 1) {
+		// Two line items of $0.5 a day.
+		for i := 0; i < 2; i++ {
+			fmt.Fprintf(&b, "999999999999,%s,%s,%sT00:00:00Z,Usage,AmazonEC2,APN1-BoxUsage:t3.micro,0.5\n", accountId, accountName, d.Format("2006-01-02"))
+		}
+	}
+	fmt.Fprintf(&b, "999999999999,%s,%s,2023-07-01T00:00:00Z,Credit,AmazonEC2,APN1-BoxUsage:t3.micro,-10\n", accountId, accountName)
+	fmt.Fprintf(&b, "999999999999,%s,%s,2023-07-01T00:00:00Z,Fee,AWSSupportBusiness,Dollar,100\n", accountId, accountName)
+	return b.String()
+}
+
+func TestLoadCurFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cur-00001.csv"), []byte(curCsv("111111111111", "dev")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The legacy CUR, gzipped in a sub directory.
+	if err := os.MkdirAll(filepath.Join(dir, "legacy"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(dir, "legacy", "cur-00001.csv.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	fmt.Fprint(gz, "identity/LineItemId,lineItem/UsageAccountId,lineItem/UsageStartDate,lineItem/LineItemType,lineItem/ProductCode,product/ProductName,lineItem/UnblendedCost\n")
+	fmt.Fprint(gz, "a,222222222222,2023-07-17T00:00:00Z,Usage,AmazonS3,Amazon Simple Storage Service,3\n")
+	gz.Close()
+	f.Close()
+
+	src, err := LoadCurFiles(dir)
+	if err != nil {
+		t.Fatalf("LoadCurFiles() error = %v", err)
+	}
+	accounts := src.Accounts()
+	if len(accounts) != 2 || *accounts["111111111111"].Name != "dev" || *accounts["222222222222"].Name != "222222222222" {
+		t.Errorf("Accounts() = %v", accounts)
+	}
+	if payers := src.PayerAccounts(); len(payers) != 1 || payers["111111111111"] != "999999999999" {
+		t.Errorf("PayerAccounts() = %v, want the payer of 111111111111 only", payers)
+	}
+
+	defer func(ce CeGetCostAndUsageAPI, f CeGetCostForecastAPI, d CeGetDimensionValuesAPI) {
+		ceClient, ceForecastClient, ceDimensionClient = ce, f, d
+	}(ceClient, ceForecastClient, ceDimensionClient)
+	UseDataSource(src)
+
+	// The credit is excluded by default, and the support fee is not.
+	opt := NewGetCostsOption(time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC))
+	opt.IncludeForecast = true
+	costs, err := GetCosts(context.Background(), accounts, opt)
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	dev := costs["111111111111"]
+	if dev.AmountLastMonth != 30 || dev.AmountThisMonth != 117 || dev.LatestDailyCostIncrease != 1 || dev.LatestWeeklyCostIncrease != 7 || dev.ForecastThisMonth != nil {
+		t.Errorf("GetCosts() dev = %+v", dev)
+	}
+	if s3 := costs["222222222222"]; s3.AmountThisMonth != 3 || s3.LatestDailyCostIncrease != 3 {
+		t.Errorf("GetCosts() s3 = %+v", s3)
+	}
+
+	opt.ExcludeSupport = true
+	costs, err = GetCosts(context.Background(), accounts, opt)
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	if got := costs["111111111111"].AmountThisMonth; got != 17 {
+		t.Errorf("GetCosts() with ExcludeSupport AmountThisMonth = %v, want 17", got)
+	}
+
+	breakdown, err := GetCostBreakdown(context.Background(), BreakdownQuery{AccountID: "222222222222", GroupBy: BreakdownByService}, opt)
+	if err != nil {
+		t.Fatalf("GetCostBreakdown() error = %v", err)
+	}
+	if len(breakdown) != 1 || breakdown[0].Key != "Amazon Simple Storage Service" || breakdown[0].AmountThisMonth != 3 {
+		t.Errorf("GetCostBreakdown() = %+v", breakdown)
+	}
+
+	// Closed months with the MONTHLY granularity.
+	costs, err = GetCosts(context.Background(), accounts, NewClosedMonthOption(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)))
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	if got := costs["111111111111"].AmountLastMonth; got != 30 {
+		t.Errorf("GetCosts() of June AmountLastMonth = %v, want 30", got)
+	}
+}
+
+// curParquetRow is a row of a CUR 2.0 Parquet file, which has the "product" map column.
+type curParquetRow struct {
+	AccountId    string            `parquet:"line_item_usage_account_id"`
+	StartDate    time.Time         `parquet:"line_item_usage_start_date,timestamp(millisecond)"`
+	LineItemType string            `parquet:"line_item_line_item_type"`
+	ProductCode  string            `parquet:"line_item_product_code"`
+	Product      map[string]string `parquet:"product"`
+	UsageType    string            `parquet:"line_item_usage_type"`
+	Cost         float64           `parquet:"line_item_unblended_cost"`
+}
+
+func TestLoadCurFiles_parquet(t *testing.T) {
+	var rows []curParquetRow
+	for d := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC); d.Before(time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)); d = d.AddDate(0, 0, 1) {
+		// Hourly line items of the same usage type, which are summed up by the day.
+		for h := 0; h < 24; h++ {
+			rows = append(rows, curParquetRow{
+				AccountId: "111111111111", StartDate: d.Add(time.Duration(h-12) * time.Hour), LineItemType: "Usage",
+				ProductCode: "AmazonEC2", Product: map[string]string{"region": "ap-northeast-1"}, UsageType: "APN1-BoxUsage:t3.micro", Cost: 0.25,
+			})
+		}
+	}
+	rows = append(rows, curParquetRow{AccountId: "111111111111", StartDate: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), LineItemType: "Fee", ProductCode: "AWSSupportBusiness", UsageType: "Dollar", Cost: 100})
+	dir := t.TempDir()
+	if err := parquet.WriteFile(filepath.Join(dir, "cur-00001.snappy.parquet"), rows); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := LoadCurFiles(dir)
+	if err != nil {
+		t.Fatalf("LoadCurFiles() error = %v", err)
+	}
+	if len(src.items) != 17+1 {
+		t.Errorf("LoadCurFiles() = %d line items, want a line item per day and the fee", len(src.items))
+	}
+	for _, li := range src.items {
+		if li.RecordType == "Usage" && (li.Amount != 6 || li.Service != "AmazonEC2" || li.UsageType != "APN1-BoxUsage:t3.micro") {
+			t.Errorf("LoadCurFiles() line item = %+v, want $6 a day of AmazonEC2", li)
+		}
+		if li.RecordType == "Support" && li.Date != "2023-07-01" {
+			t.Errorf("LoadCurFiles() support fee date = %v, want 2023-07-01", li.Date)
+		}
+	}
+	if got := *src.Accounts()["111111111111"].Name; got != "111111111111" {
+		t.Errorf("Accounts() name = %v, want the account ID", got)
+	}
+}
+
+func Test_int96Time(t *testing.T) {
+	// 2023-07-18T01:02:03Z is the Julian day 2460144 and 3723 seconds of the day.
+	nanos := uint64(3723 * time.Second)
+	got := int96Time(deprecated.Int96{uint32(nanos), uint32(nanos >> 32), 2460144})
+	if want := time.Date(2023, 7, 18, 1, 2, 3, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("int96Time() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadCurFiles_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "invalid parquet", file: "cur-00001.snappy.parquet", content: "PAR1", wantErr: "cur-00001.snappy.parquet"},
+		{name: "no files", file: "README.txt", content: "", wantErr: "no Cost and Usage Report files"},
+		{name: "missing column", file: "cur.csv", content: "line_item_usage_account_id,line_item_unblended_cost\n", wantErr: "line_item_usage_start_date"},
+		{name: "invalid cost", file: "cur.csv", content: "line_item_usage_account_id,line_item_usage_start_date,line_item_unblended_cost\n111111111111,2023-07-01T00:00:00Z,abc\n", wantErr: "at line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadCurFiles(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadCurFiles() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_normalizeCurColumn(t *testing.T) {
+	for in, want := range map[string]string{
+		"lineItem/UsageAccountId":    "line_item_usage_account_id",
+		"line_item_usage_account_id": "line_item_usage_account_id",
+		"product/ProductName":        "product_product_name",
+		"\ufefflineItem/UsageType":   "line_item_usage_type",
+	} {
+		if got := normalizeCurColumn(in); got != want {
+			t.Errorf("normalizeCurColumn(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package acos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// DemoMonths is the number of the months of the costs in the demo organization, including this month.
+const DemoMonths = 14
+
+// demoAccountSpec represents an account of the demo organization.
+type demoAccountSpec struct {
+	Name    string
+	Ou      string  // The name of the parent OU, or empty for the Root.
+	Team    string  // The value of the "team" tag.
+	Daily   float64 // The average daily cost in USD.
+	Weekend float64 // The ratio of the costs on weekends to weekdays.
+	Mix     string  // The key of demoServiceMixes.
+}
+
+// demoAccountSpecs are the accounts of the demo organization. The management account is the first one.
+var demoAccountSpecs = []demoAccountSpec{
+	{Name: "acme-management", Team: "platform", Daily: 8, Weekend: 1, Mix: "governance"},
+	{Name: "acme-security-audit", Ou: "Security", Team: "security", Daily: 14, Weekend: 1, Mix: "governance"},
+	{Name: "acme-log-archive", Ou: "Security", Team: "security", Daily: 18, Weekend: 1, Mix: "storage"},
+	{Name: "acme-prod-web", Ou: "Production", Team: "web", Daily: 210, Weekend: 0.85, Mix: "web"},
+	{Name: "acme-prod-api", Ou: "Production", Team: "api", Daily: 340, Weekend: 0.8, Mix: "web"},
+	{Name: "acme-prod-data", Ou: "Production", Team: "data", Daily: 520, Weekend: 0.9, Mix: "data"},
+	{Name: "acme-staging", Ou: "Development", Team: "platform", Daily: 75, Weekend: 0.6, Mix: "web"},
+	{Name: "acme-dev-web", Ou: "Development", Team: "web", Daily: 32, Weekend: 0.4, Mix: "web"},
+	{Name: "acme-dev-api", Ou: "Development", Team: "api", Daily: 38, Weekend: 0.4, Mix: "web"},
+	{Name: "acme-dev-data", Ou: "Development", Team: "data", Daily: 46, Weekend: 0.5, Mix: "data"},
+	{Name: "acme-sandbox-1", Ou: "Sandbox", Team: "platform", Daily: 6, Weekend: 0.2, Mix: "web"},
+	{Name: "acme-sandbox-2", Ou: "Sandbox", Team: "web", Daily: 4, Weekend: 0.2, Mix: "web"},
+}
+
+// demoCostCenters are the values of the "cost-center" tag by the teams.
+var demoCostCenters = map[string]string{"platform": "cc-100", "security": "cc-110", "web": "cc-200", "api": "cc-210", "data": "cc-300"}
+
+const (
+	demoSuspendedAccount = "acme-sandbox-1"   // Suspended 20 days ago.
+	demoNewAccount       = "acme-sandbox-2"   // Joined the organization on the first day of this month.
+	demoLegacyAccount    = "acme-legacy-prod" // Left the organization in the last month, so that it's only in the Cost Explorer data.
+)
+
+// demoServiceShare represents a service and its share of the costs of an account.
+type demoServiceShare struct {
+	Service    string
+	Share      float64
+	UsageTypes [2]string // The first usage type takes 70% of the costs of the service.
+}
+
+var (
+	demoCompute    = demoServiceShare{"Amazon Elastic Compute Cloud - Compute", 0, [2]string{"BoxUsage:m5.large", "BoxUsage:t3.medium"}}
+	demoEc2Other   = demoServiceShare{"EC2 - Other", 0, [2]string{"EBS:VolumeUsage.gp3", "NatGateway-Hours"}}
+	demoRds        = demoServiceShare{"Amazon Relational Database Service", 0, [2]string{"InstanceUsage:db.r6g.large", "RDS:GP3-Storage"}}
+	demoS3         = demoServiceShare{"Amazon Simple Storage Service", 0, [2]string{"TimedStorage-ByteHrs", "Requests-Tier1"}}
+	demoCloudFront = demoServiceShare{"Amazon CloudFront", 0, [2]string{"US-DataTransfer-Out-Bytes", "US-Requests-Tier1"}}
+	demoCloudWatch = demoServiceShare{"AmazonCloudWatch", 0, [2]string{"CW:MetricMonitorUsage", "DataProcessing-Bytes"}}
+	demoLambda     = demoServiceShare{"AWS Lambda", 0, [2]string{"Lambda-GB-Second", "Request"}}
+	demoDynamoDb   = demoServiceShare{"Amazon DynamoDB", 0, [2]string{"ReadCapacityUnit-Hrs", "TimedStorage-ByteHrs"}}
+	demoRedshift   = demoServiceShare{"Amazon Redshift", 0, [2]string{"Node:ra3.xlplus", "RMS:ManagedStorage"}}
+	demoGlue       = demoServiceShare{"AWS Glue", 0, [2]string{"Crawler-DPU-Hour", "ETL-DPU-Hour"}}
+	demoCloudTrail = demoServiceShare{"AWS CloudTrail", 0, [2]string{"PaidEventsRecorded", "DataEventsRecorded"}}
+	demoConfig     = demoServiceShare{"AWS Config", 0, [2]string{"ConfigurationItemRecorded", "ConfigRuleEvaluations"}}
+	demoGuardDuty  = demoServiceShare{"Amazon GuardDuty", 0, [2]string{"PaidEventsAnalyzed", "PaidS3DataEventsAnalyzed"}}
+)
+
+// demoServiceMixes are the shares of the services by the kinds of the accounts.
+var demoServiceMixes = map[string][]demoServiceShare{
+	"web": {
+		withShare(demoCompute, 0.38), withShare(demoEc2Other, 0.12), withShare(demoRds, 0.2), withShare(demoS3, 0.07),
+		withShare(demoCloudFront, 0.1), withShare(demoCloudWatch, 0.05), withShare(demoLambda, 0.05), withShare(demoDynamoDb, 0.03),
+	},
+	"data": {
+		withShare(demoRedshift, 0.35), withShare(demoS3, 0.2), withShare(demoGlue, 0.15), withShare(demoCompute, 0.15),
+		withShare(demoEc2Other, 0.08), withShare(demoCloudWatch, 0.04), withShare(demoLambda, 0.03),
+	},
+	"storage": {
+		withShare(demoS3, 0.85), withShare(demoCloudWatch, 0.1), withShare(demoLambda, 0.05),
+	},
+	"governance": {
+		withShare(demoCloudTrail, 0.3), withShare(demoConfig, 0.3), withShare(demoGuardDuty, 0.25), withShare(demoS3, 0.15),
+	},
+}
+
+func withShare(s demoServiceShare, share float64) demoServiceShare {
+	s.Share = share
+	return s
+}
+
+// demoAccount represents an account of the demo organization with its parent and tags.
+type demoAccount struct {
+	Account
+	ParentId string
+	Tags     map[string]string
+}
+
+// DemoSource is a fake of the AWS Cost Explorer, AWS Organizations, AWS STS and IAM APIs used by acos, which serves
+// a synthetic organization with OUs, accounts and the daily costs of the services. See NewDemoSource and UseDemo.
+//
+// The costs have the weekly seasonality, a monthly growth, and occasional spikes. The organization also has a suspended
+// account, an account which joined this month, and an account which left the organization but still has costs.
+type DemoSource struct {
+	*LineItemSource
+	managementAccountId string
+	rootId              string
+	ous                 map[string]string // map[ouId]name
+	accounts            []demoAccount
+}
+
+// NewDemoSource returns the demo organization generated from the seed, with the costs of DemoMonths months until
+// the day before `now`. The same seed and date always generate the same organization and costs.
+func NewDemoSource(seed int64, now time.Time) *DemoSource {
+	rng := rand.New(rand.NewSource(seed))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := thisMonth.AddDate(0, -(DemoMonths - 1), 0)
+
+	s := &DemoSource{
+		rootId: "r-" + demoRandomString(rng, 4),
+		ous:    make(map[string]string),
+	}
+	ouIds := make(map[string]string) // map[name]ouId
+	usedIds := make(map[string]bool)
+	newAccountId := func() string {
+		for {
+			id := fmt.Sprintf("%012d", rng.Int63n(1e12))
+			if !usedIds[id] {
+				usedIds[id] = true
+				return id
+			}
+		}
+	}
+
+	specs := append(append([]demoAccountSpec{}, demoAccountSpecs...), demoAccountSpec{Name: demoLegacyAccount, Ou: "Production", Team: "api", Daily: 120, Weekend: 0.8, Mix: "web"})
+	var items []LineItem
+	for _, spec := range specs {
+		id := newAccountId()
+		joined := start.AddDate(0, -rng.Intn(24), -rng.Intn(28))
+		from, until := start, today // The costs are in [from, until).
+		status := types.AccountStatusActive
+		switch spec.Name {
+		case demoSuspendedAccount:
+			status = types.AccountStatusSuspended
+			until = today.AddDate(0, 0, -20)
+		case demoNewAccount:
+			joined = thisMonth
+			from = thisMonth
+		case demoLegacyAccount:
+			until = thisMonth.AddDate(0, 0, -10)
+		}
+		items = append(items, demoLineItems(rng, id, spec, from, until, start)...)
+		if spec.Name == demoLegacyAccount {
+			continue
+		}
+
+		parentId := s.rootId
+		if len(spec.Ou) > 0 {
+			if _, ok := ouIds[spec.Ou]; !ok {
+				ouId := fmt.Sprintf("ou-%s-%s", s.rootId[len("r-"):], demoRandomString(rng, 8))
+				ouIds[spec.Ou], s.ous[ouId] = ouId, spec.Ou
+			}
+			parentId = ouIds[spec.Ou]
+		}
+		if len(s.managementAccountId) == 0 {
+			s.managementAccountId = id
+		}
+		env := strings.ToLower(spec.Ou)
+		if len(env) == 0 {
+			env = "management"
+		}
+		s.accounts = append(s.accounts, demoAccount{
+			Account: Account{
+				Id:              aws.String(id),
+				Name:            aws.String(spec.Name),
+				Arn:             aws.String(fmt.Sprintf("arn:aws:organizations::%s:account/o-%s/%s", s.managementAccountId, s.rootId[len("r-"):], id)),
+				Email:           aws.String(spec.Name + "@example.com"),
+				Status:          status,
+				JoinedMethod:    types.AccountJoinedMethodCreated,
+				JoinedTimestamp: aws.Time(joined),
+			},
+			ParentId: parentId,
+			Tags:     map[string]string{"env": env, "team": spec.Team, "cost-center": demoCostCenters[spec.Team]},
+		})
+	}
+	// The AWS Support fee of the organization is charged to the management account on the first day of each month.
+	for m := start; m.Before(today); m = m.AddDate(0, 1, 0) {
+		items = append(items, LineItem{
+			AccountID:   s.managementAccountId,
+			AccountName: demoAccountSpecs[0].Name,
+			Date:        m.Format("2006-01-02"),
+			RecordType:  "Support",
+			Service:     "AWS Support (Business)",
+			UsageType:   "Dollar",
+			Amount:      demoRound(100 + 150*rng.Float64()),
+		})
+	}
+	s.LineItemSource = NewLineItemSource(items)
+	return s
+}
+
+// demoLineItems returns the line items of the account in [from, until), with the monthly growth since `start`.
+func demoLineItems(rng *rand.Rand, accountId string, spec demoAccountSpec, from, until, start time.Time) []LineItem {
+	var items []LineItem
+	// Each account has its own mix, slightly different from the others.
+	services := demoServiceMixes[spec.Mix]
+	weights := make([]float64, len(services))
+	for i, svc := range services {
+		weights[i] = svc.Share * (0.7 + 0.6*rng.Float64())
+	}
+	for d := from; d.Before(until); d = d.AddDate(0, 0, 1) {
+		months := float64(d.Year()-start.Year())*12 + float64(d.Month()-start.Month())
+		daily := spec.Daily * (1 + 0.02*months)
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			daily *= spec.Weekend
+		}
+		for i, svc := range services {
+			amount := daily * weights[i] * math.Max(0.5, 1+0.08*rng.NormFloat64())
+			if rng.Float64() < 0.004 {
+				// A spike, e.g. a load test or a misconfiguration.
+				amount *= 3 + 4*rng.Float64()
+			}
+			for j, usageType := range svc.UsageTypes {
+				share := 0.7
+				if j == 1 {
+					share = 0.3
+				}
+				items = append(items, LineItem{
+					AccountID:   accountId,
+					AccountName: spec.Name,
+					Date:        d.Format("2006-01-02"),
+					RecordType:  "Usage",
+					Service:     svc.Service,
+					UsageType:   usageType,
+					Amount:      demoRound(amount * share),
+				})
+			}
+		}
+	}
+	return items
+}
+
+func demoRound(amount float64) float64 {
+	return math.Round(amount*1e6) / 1e6
+}
+
+func demoRandomString(rng *rand.Rand, n int) string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[rng.Intn(len(chars))]
+	}
+	return string(b)
+}
+
+// UseDemo replaces all the AWS API clients with the demo organization, so that acos works without AWS.
+// It should be called after Configure and EnableRequestCounter, and before EnableCache.
+func UseDemo(s *DemoSource) {
+	UseDataSource(s)
+	organizationsClient = s
+	orgListTagsClient = s
+	stsClient = s
+	iamClient = s
+}
+
+// GetCostForecast forecasts the costs of the period from the average daily costs of the last 7 days before it.
+func (s *DemoSource) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	if params.TimePeriod == nil || params.TimePeriod.Start == nil || params.TimePeriod.End == nil {
+		return nil, fmt.Errorf("error the time period is required")
+	}
+	start, err1 := time.Parse("2006-01-02", *params.TimePeriod.Start)
+	end, err2 := time.Parse("2006-01-02", *params.TimePeriod.End)
+	if err1 != nil || err2 != nil || !start.Before(end) {
+		return nil, fmt.Errorf("error invalid time period %s - %s", *params.TimePeriod.Start, *params.TimePeriod.End)
+	}
+	out, err := s.GetCostAndUsage(ctx, &costexplorer.GetCostAndUsageInput{
+		Granularity: cetypes.GranularityDaily,
+		Metrics:     []string{ceCostMetric},
+		TimePeriod:  &cetypes.DateInterval{Start: aws.String(start.AddDate(0, 0, -7).Format("2006-01-02")), End: params.TimePeriod.Start},
+		Filter:      params.Filter,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sum := 0.0
+	for _, r := range out.ResultsByTime {
+		f, _ := strconv.ParseFloat(*r.Total[ceCostMetric].Amount, 64)
+		sum += f
+	}
+	if sum == 0 {
+		return nil, &cetypes.DataUnavailableException{Message: aws.String("not enough data to forecast the costs")}
+	}
+	forecast := metricValue(demoRound(sum / 7 * end.Sub(start).Hours() / 24))
+	return &costexplorer.GetCostForecastOutput{Total: &forecast}, nil
+}
+
+func (s *DemoSource) ListAccounts(ctx context.Context, params *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error) {
+	out := &organizations.ListAccountsOutput{}
+	for _, a := range s.accounts {
+		out.Accounts = append(out.Accounts, types.Account(a.Account))
+	}
+	return out, nil
+}
+
+func (s *DemoSource) ListAccountsForParent(ctx context.Context, params *organizations.ListAccountsForParentInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsForParentOutput, error) {
+	parentId := aws.ToString(params.ParentId)
+	if _, ok := s.ous[parentId]; !ok && parentId != s.rootId {
+		return nil, &types.ParentNotFoundException{Message: aws.String("the parent is not found in the demo organization")}
+	}
+	out := &organizations.ListAccountsForParentOutput{}
+	for _, a := range s.accounts {
+		if a.ParentId == parentId {
+			out.Accounts = append(out.Accounts, types.Account(a.Account))
+		}
+	}
+	return out, nil
+}
+
+func (s *DemoSource) ListParents(ctx context.Context, params *organizations.ListParentsInput, optFns ...func(*organizations.Options)) (*organizations.ListParentsOutput, error) {
+	a, ok := s.account(aws.ToString(params.ChildId))
+	if !ok {
+		return nil, &types.ChildNotFoundException{Message: aws.String("the account is not found in the demo organization")}
+	}
+	parentType := types.ParentTypeOrganizationalUnit
+	if a.ParentId == s.rootId {
+		parentType = types.ParentTypeRoot
+	}
+	return &organizations.ListParentsOutput{Parents: []types.Parent{{Id: aws.String(a.ParentId), Type: parentType}}}, nil
+}
+
+func (s *DemoSource) DescribeOrganizationalUnit(ctx context.Context, params *organizations.DescribeOrganizationalUnitInput, optFns ...func(*organizations.Options)) (*organizations.DescribeOrganizationalUnitOutput, error) {
+	name, ok := s.ous[aws.ToString(params.OrganizationalUnitId)]
+	if !ok {
+		return nil, &types.OrganizationalUnitNotFoundException{Message: aws.String("the OU is not found in the demo organization")}
+	}
+	return &organizations.DescribeOrganizationalUnitOutput{
+		OrganizationalUnit: &types.OrganizationalUnit{Id: params.OrganizationalUnitId, Name: aws.String(name)},
+	}, nil
+}
+
+func (s *DemoSource) ListTagsForResource(ctx context.Context, params *organizations.ListTagsForResourceInput, optFns ...func(*organizations.Options)) (*organizations.ListTagsForResourceOutput, error) {
+	a, ok := s.account(aws.ToString(params.ResourceId))
+	if !ok {
+		return nil, &types.TargetNotFoundException{Message: aws.String("the account is not found in the demo organization")}
+	}
+	out := &organizations.ListTagsForResourceOutput{}
+	for _, k := range []string{"cost-center", "env", "team"} {
+		out.Tags = append(out.Tags, types.Tag{Key: aws.String(k), Value: aws.String(a.Tags[k])})
+	}
+	return out, nil
+}
+
+func (s *DemoSource) account(id string) (demoAccount, bool) {
+	for _, a := range s.accounts {
+		if *a.Id == id {
+			return a, true
+		}
+	}
+	return demoAccount{}, false
+}
+
+// GetCallerIdentity returns the management account of the demo organization as the caller.
+func (s *DemoSource) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String(s.managementAccountId),
+		Arn:     aws.String(fmt.Sprintf("arn:aws:sts::%s:assumed-role/AcosDemo/demo", s.managementAccountId)),
+		UserId:  aws.String("AROADEMO:demo"),
+	}, nil
+}
+
+func (s *DemoSource) ListAccountAliases(ctx context.Context, params *iam.ListAccountAliasesInput, optFns ...func(*iam.Options)) (*iam.ListAccountAliasesOutput, error) {
+	return &iam.ListAccountAliasesOutput{AccountAliases: []string{demoAccountSpecs[0].Name}}, nil
+}
@@ -0,0 +1,117 @@
+package acos
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNewDemoSource(t *testing.T) {
+	now := time.Date(2023, 7, 18, 9, 0, 0, 0, time.UTC)
+	a, b := NewDemoSource(1, now), NewDemoSource(1, now)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("NewDemoSource() generated different organizations for the same seed")
+	}
+	if reflect.DeepEqual(a.accounts, NewDemoSource(2, now).accounts) {
+		t.Errorf("NewDemoSource() generated the same organization for different seeds")
+	}
+
+	first, last := a.items[0].Date, a.items[0].Date
+	weekdays, weekends := 0.0, 0.0
+	for _, li := range a.items {
+		if li.Date < first {
+			first = li.Date
+		}
+		if li.Date > last {
+			last = li.Date
+		}
+		if d, _ := time.Parse("2006-01-02", li.Date); li.RecordType == "Usage" && d.Weekday() == time.Sunday {
+			weekends += li.Amount
+		} else if li.RecordType == "Usage" && d.Weekday() == time.Wednesday {
+			weekdays += li.Amount
+		}
+	}
+	if first != "2022-06-01" || last != "2023-07-17" {
+		t.Errorf("NewDemoSource() costs from %s to %s, want from 2022-06-01 to 2023-07-17", first, last)
+	}
+	if weekends >= weekdays {
+		t.Errorf("NewDemoSource() costs on Sundays = %v, want less than on Wednesdays = %v", weekends, weekdays)
+	}
+}
+
+func TestWithDemo_GetCosts(t *testing.T) {
+	now := time.Now().UTC()
+	UseDemo(NewDemoSource(1, now))
+	ctx := context.Background()
+
+	accounts, err := ListAccounts(ctx)
+	if err != nil {
+		t.Fatalf("ListAccounts() error = %v", err)
+	}
+	if len(accounts) != len(demoAccountSpecs) {
+		t.Fatalf("ListAccounts() = %d accounts, want %d", len(accounts), len(demoAccountSpecs))
+	}
+	ids := accounts.AccountIds()
+	sort.Strings(ids)
+
+	ous, err := ListAccountOus(ctx, ids)
+	if err != nil {
+		t.Fatalf("ListAccountOus() error = %v", err)
+	}
+	byOu := make(map[string]Accounts)
+	for id, ou := range ous {
+		if byOu[ou.Id] == nil {
+			byOu[ou.Id] = make(Accounts)
+		}
+		byOu[ou.Id][id] = accounts[id]
+	}
+	for ouId, want := range byOu {
+		got, err := ListAccountsByOu(ctx, ouId)
+		if err != nil || len(got) != len(want) {
+			t.Errorf("ListAccountsByOu(%s) = %d accounts, %v, want %d accounts", ouId, len(got), err, len(want))
+		}
+	}
+	if _, err := ListAccountsByOu(ctx, "ou-none-00000000"); err == nil {
+		t.Errorf("ListAccountsByOu() error = nil, want error for an unknown OU")
+	}
+	tags, err := ListAccountTags(ctx, ids)
+	if err != nil || tags[ids[0]]["team"] == "" {
+		t.Errorf("ListAccountTags() = %v, %v, want the team tags", tags[ids[0]], err)
+	}
+	if caller, err := GetCallerAccount(ctx); err != nil || caller[1] != "acme-management" {
+		t.Errorf("GetCallerAccount() = %v, %v, want the management account", caller, err)
+	}
+
+	opt := NewGetCostsOption(now)
+	opt.IncludeForecast = true
+	opt.AllLinkedAccounts = true
+	costs, err := GetCosts(ctx, accounts, opt)
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	// The legacy account is only in the Cost Explorer data.
+	if len(costs) != len(demoAccountSpecs)+1 {
+		t.Errorf("GetCosts() = %d costs, want %d", len(costs), len(demoAccountSpecs)+1)
+	}
+	for id, c := range costs {
+		switch c.AccountName {
+		case demoLegacyAccount:
+			if c.AccountStatus != AccountStatusNotInOrganization || c.AmountThisMonth != 0 {
+				t.Errorf("GetCosts() %s = %s, $%v this month, want not in the organization without costs", c.AccountName, c.AccountStatus, c.AmountThisMonth)
+			}
+		case demoNewAccount:
+			if !c.JoinedThisMonth || c.AmountLastMonth != 0 {
+				t.Errorf("GetCosts() %s joined this month = %v, $%v last month, want joined this month without costs", c.AccountName, c.JoinedThisMonth, c.AmountLastMonth)
+			}
+		default:
+			if c.AmountLastMonth <= 0 {
+				t.Errorf("GetCosts() %s last month = %v, want positive", id, c.AmountLastMonth)
+			}
+			if now.Day() > 1 && c.AccountStatus == "ACTIVE" && c.ForecastThisMonth == nil {
+				t.Errorf("GetCosts() %s forecast = nil, want the forecast", id)
+			}
+		}
+	}
+}
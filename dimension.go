@@ -0,0 +1,100 @@
+package acos
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+type CeGetDimensionValuesAPI interface {
+	GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error)
+}
+
+var (
+	// AWS clients
+	ceDimensionClient CeGetDimensionValuesAPI
+)
+
+func init() {
+	ceDimensionClient = costexplorer.NewFromConfig(cfg)
+}
+
+const (
+	// AccountStatusNotInOrganization is the Cost.AccountStatus of the accounts which have costs but are not in the organization,
+	// e.g. the accounts which left the organization. See AcosGetCostsOption.AllLinkedAccounts.
+	AccountStatusNotInOrganization = "NOT_IN_ORGANIZATION"
+	// unknownAccountName is the Cost.AccountName of the accounts not in the organization whose names are not available.
+	unknownAccountName = "(not in organization)"
+	// ceAccountNameAttribute is the attribute of the LINKED_ACCOUNT dimension values, which contains the account name.
+	ceAccountNameAttribute = "description"
+)
+
+// fillUnknownAccountNames fills the names of the accounts which are not in the organization,
+// using the attributes of the LINKED_ACCOUNT dimension values in the same period as the costs.
+// The names are unknownAccountName when the API fails, and it returns the error for each of those accounts,
+// so that the costs already retrieved are not thrown away for the names.
+func fillUnknownAccountNames(ctx context.Context, costs Costs, opt AcosGetCostsOption) map[string]error {
+	var unknownIds []string
+	for id, c := range costs {
+		if c.AccountStatus == AccountStatusNotInOrganization {
+			unknownIds = append(unknownIds, id)
+		}
+	}
+	if len(unknownIds) == 0 {
+		return nil
+	}
+
+	names, err := getLinkedAccountNames(ctx, opt)
+	failures := make(map[string]error)
+	for _, id := range unknownIds {
+		c := costs[id]
+		c.AccountName = unknownAccountName
+		if name := names[id]; len(name) > 0 {
+			c.AccountName = name
+		}
+		costs[id] = c
+		if err != nil {
+			failures[id] = fmt.Errorf("error failed to get the account name: %w", err)
+		}
+	}
+	return failures
+}
+
+// getLinkedAccountNames returns the names of the linked accounts in the Cost Explorer data. The map key is the account ID.
+func getLinkedAccountNames(ctx context.Context, opt AcosGetCostsOption) (map[string]string, error) {
+	names := make(map[string]string)
+	var nextToken *string
+	for {
+		in := linkedAccountsInput(opt)
+		in.NextPageToken = nextToken
+		out, err := ceDimensionClient.GetDimensionValues(ctx, &in)
+		if err != nil {
+			return nil, classifyError("ce:GetDimensionValues", err)
+		}
+		for _, v := range out.DimensionValues {
+			if v.Value != nil {
+				names[*v.Value] = v.Attributes[ceAccountNameAttribute]
+			}
+		}
+		nextToken = out.NextPageToken
+		if nextToken == nil {
+			break
+		}
+	}
+	return names, nil
+}
+
+// linkedAccountsInput returns the GetDimensionValuesInput param for the linked accounts in the same period as the costs.
+func linkedAccountsInput(opt AcosGetCostsOption) costexplorer.GetDimensionValuesInput {
+	return costexplorer.GetDimensionValuesInput{
+		Dimension: types.DimensionLinkedAccount,
+		Context:   types.ContextCostAndUsage,
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(opt.dates.firstDayOfLastMonth),
+			End:   aws.String(opt.dates.asOf),
+		},
+	}
+}
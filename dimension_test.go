@@ -0,0 +1,118 @@
+package acos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+type mockGetDimensionValuesAPI func(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error)
+
+func (m mockGetDimensionValuesAPI) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	return m(ctx, params, optFns...)
+}
+
+func TestWithMock_GetCosts_allLinkedAccounts(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		t.Helper()
+		if params.Filter.Dimensions != nil && params.Filter.Dimensions.Key == ceCostGroupBy {
+			t.Errorf("GetCostAndUsage() filtered by accounts = %v", params.Filter.Dimensions.Values)
+		}
+		one := func(time.Time) float64 { return 1 }
+		var results []types.ResultByTime
+		results = append(results, dailyResults("111111111111", start, asOf, one)...)
+		results = append(results, dailyResults("222222222222", start, asOf, one)...)
+		results = append(results, dailyResults("333333333333", start, asOf, one)...)
+		return &costexplorer.GetCostAndUsageOutput{ResultsByTime: results}, nil
+	})
+	ceDimensionClient = mockGetDimensionValuesAPI(func(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+		t.Helper()
+		return &costexplorer.GetDimensionValuesOutput{
+			DimensionValues: []types.DimensionValuesWithAttributes{
+				{Value: toPointer("111111111111"), Attributes: map[string]string{"description": "member"}},
+				{Value: toPointer("222222222222"), Attributes: map[string]string{"description": "left-the-org"}},
+			},
+		}, nil
+	})
+
+	opt := NewGetCostsOption(asOf)
+	opt.ExcludeCredit, opt.ExcludeUpfront = true, false
+	opt.AllLinkedAccounts = true
+	got, err := GetCosts(context.Background(), Accounts{
+		"111111111111": Account{Id: toPointer("111111111111"), Name: toPointer("member"), Status: "ACTIVE"},
+	}, opt)
+	if err != nil {
+		t.Fatalf("GetCosts() error = %v", err)
+	}
+	for _, tt := range []struct {
+		id, wantName, wantStatus string
+	}{
+		{"111111111111", "member", "ACTIVE"},
+		{"222222222222", "left-the-org", AccountStatusNotInOrganization},
+		{"333333333333", unknownAccountName, AccountStatusNotInOrganization},
+	} {
+		c, ok := got[tt.id]
+		if !ok {
+			t.Errorf("GetCosts() doesn't contain %s", tt.id)
+			continue
+		}
+		if c.AccountName != tt.wantName || c.AccountStatus != tt.wantStatus || c.AmountThisMonth != 17 {
+			t.Errorf("GetCosts()[%s] = %v, %v, %v, want %v, %v, 17", tt.id, c.AccountName, c.AccountStatus, c.AmountThisMonth, tt.wantName, tt.wantStatus)
+		}
+	}
+}
+
+func TestWithMock_GetCosts_allLinkedAccountsWithoutNames(t *testing.T) {
+	asOf := time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	ceClient = mockGetCostAndUsageAPI(func(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+		t.Helper()
+		one := func(time.Time) float64 { return 1 }
+		var results []types.ResultByTime
+		results = append(results, dailyResults("111111111111", start, asOf, one)...)
+		results = append(results, dailyResults("222222222222", start, asOf, one)...)
+		return &costexplorer.GetCostAndUsageOutput{ResultsByTime: results}, nil
+	})
+	ceDimensionClient = mockGetDimensionValuesAPI(func(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+		t.Helper()
+		return nil, errors.New("throttled")
+	})
+
+	opt := NewGetCostsOption(asOf)
+	opt.AllLinkedAccounts = true
+	got, err := GetCosts(context.Background(), Accounts{
+		"111111111111": Account{Id: toPointer("111111111111"), Name: toPointer("member"), Status: "ACTIVE"},
+	}, opt)
+	var partialErr *PartialError
+	if !errors.As(err, &partialErr) || len(partialErr.Failures) != 1 || partialErr.Failures["222222222222"] == nil {
+		t.Fatalf("GetCosts() error = %v, want a PartialError of 222222222222", err)
+	}
+	if len(got) != 2 || got["111111111111"].AccountName != "member" || got["222222222222"].AccountName != unknownAccountName || got["222222222222"].AmountThisMonth != 17 {
+		t.Errorf("GetCosts() = %v, want the costs of both accounts with the unknown name", got)
+	}
+}
+
+func Test_acosOptToFilter(t *testing.T) {
+	opt := NewGetCostsOption(time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC))
+	opt.ExcludeCredit, opt.ExcludeUpfront = false, false
+	if f := acosOptToFilter(opt, nil); f != nil {
+		t.Errorf("acosOptToFilter() = %v, want nil", f)
+	}
+	if f := acosOptToFilter(opt, []string{"111111111111"}); f == nil || f.And != nil || f.Dimensions.Values[0] != "111111111111" {
+		t.Errorf("acosOptToFilter() = %v, want the account filter only", f)
+	}
+	opt.ExcludeCredit = true
+	if f := acosOptToFilter(opt, []string{"111111111111"}); f == nil || len(f.And) != 2 {
+		t.Errorf("acosOptToFilter() = %v, want the account filter and the record type filter", f)
+	}
+	opt.UsageOnly = true
+	if f := acosOptToFilter(opt, nil); f == nil || f.Dimensions == nil || f.Dimensions.Key != "RECORD_TYPE" || len(f.Dimensions.Values) != len(usageRecordTypes) {
+		t.Errorf("acosOptToFilter() = %v, want the usage record types only", f)
+	}
+}
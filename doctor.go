@@ -0,0 +1,115 @@
+package acos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// PermissionCheck represents the result of checking an IAM permission used by acos.
+type PermissionCheck struct {
+	Action  string // The IAM action, e.g. "ce:GetCostAndUsage".
+	Feature string // The feature of acos which uses the action.
+	Err     error  // The error of the API call, or nil when the call succeeded.
+	Skipped string // The reason why the check was skipped, or empty.
+}
+
+// CheckPermissions calls every AWS API which acos uses with a minimal request, to check the IAM permissions and
+// the account settings such as Cost Explorer. The OU is used to check "organizations:ListAccountsForParent",
+// and the check is skipped when `ouId` is empty.
+//
+// Note that every Cost Explorer API request is charged, and it makes three of them.
+func CheckPermissions(ctx context.Context, ouId string) []PermissionCheck {
+	var checks []PermissionCheck
+	check := func(action, feature string, err error) {
+		checks = append(checks, PermissionCheck{Action: action, Feature: feature, Err: classifyError(action, err)})
+	}
+	skip := func(action, feature, reason string) {
+		checks = append(checks, PermissionCheck{Action: action, Feature: feature, Skipped: reason})
+	}
+
+	var accountId *string
+	caller, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	check("sts:GetCallerIdentity", "the fallback outside organizations", err)
+	if err == nil {
+		accountId = caller.Account
+	}
+	_, err = iamClient.ListAccountAliases(ctx, &iam.ListAccountAliasesInput{MaxItems: aws.Int32(1)})
+	check("iam:ListAccountAliases", "the fallback outside organizations", err)
+
+	_, err = organizationsClient.ListAccounts(ctx, &organizations.ListAccountsInput{MaxResults: aws.Int32(1)})
+	check("organizations:ListAccounts", "listing accounts", err)
+
+	if len(ouId) > 0 {
+		_, err = organizationsClient.ListAccountsForParent(ctx, &organizations.ListAccountsForParentInput{ParentId: aws.String(ouId), MaxResults: aws.Int32(1)})
+		var notFound *types.ParentNotFoundException
+		if errors.As(err, &notFound) {
+			err = &OuNotFoundError{OuId: ouId, Err: err}
+		}
+		check("organizations:ListAccountsForParent", "the -ou flag", err)
+	} else {
+		skip("organizations:ListAccountsForParent", "the -ou flag", "set the -ou flag to check it")
+	}
+
+	if accountId == nil {
+		skip("organizations:ListParents", "the ou column and the -groupBy flag", "the caller account is unknown")
+		skip("organizations:DescribeOrganizationalUnit", "the ou column and the -groupBy flag", "the caller account is unknown")
+		skip("organizations:ListTagsForResource", "the tag columns and the -accountTag flag", "the caller account is unknown")
+	} else {
+		parents, err := organizationsClient.ListParents(ctx, &organizations.ListParentsInput{ChildId: accountId})
+		check("organizations:ListParents", "the ou column and the -groupBy flag", err)
+		if err == nil && len(parents.Parents) > 0 && parents.Parents[0].Type == types.ParentTypeOrganizationalUnit {
+			_, err = organizationsClient.DescribeOrganizationalUnit(ctx, &organizations.DescribeOrganizationalUnitInput{OrganizationalUnitId: parents.Parents[0].Id})
+			check("organizations:DescribeOrganizationalUnit", "the ou column and the -groupBy flag", err)
+		} else {
+			skip("organizations:DescribeOrganizationalUnit", "the ou column and the -groupBy flag", "the caller account is not in an OU")
+		}
+		_, err = orgListTagsClient.ListTagsForResource(ctx, &organizations.ListTagsForResourceInput{ResourceId: accountId})
+		check("organizations:ListTagsForResource", "the tag columns and the -accountTag flag", err)
+	}
+
+	opt := NewGetCostsOption(time.Now().UTC())
+	_, err = ceClient.GetCostAndUsage(ctx, &costexplorer.GetCostAndUsageInput{
+		Granularity: cetypes.GranularityMonthly,
+		Metrics:     []string{ceCostMetric},
+		TimePeriod: &cetypes.DateInterval{
+			Start: aws.String(opt.dates.firstDayOfLastMonth),
+			End:   aws.String(opt.dates.asOf),
+		},
+	})
+	check("ce:GetCostAndUsage", "retrieving costs", err)
+
+	_, err = ceForecastClient.GetCostForecast(ctx, &costexplorer.GetCostForecastInput{
+		Granularity: ceForecastGranularity,
+		Metric:      ceForecastMetric,
+		TimePeriod: &cetypes.DateInterval{
+			Start: aws.String(opt.dates.asOf),
+			End:   aws.String(opt.dates.firstDayOfNextMonth),
+		},
+	})
+	var unavailable *cetypes.DataUnavailableException
+	if errors.As(err, &unavailable) {
+		// The permission is fine, but there's not enough data to forecast yet.
+		err = nil
+	}
+	check("ce:GetCostForecast", "the forecast column", err)
+
+	_, err = ceDimensionClient.GetDimensionValues(ctx, &costexplorer.GetDimensionValuesInput{
+		Dimension: cetypes.DimensionLinkedAccount,
+		TimePeriod: &cetypes.DateInterval{
+			Start: aws.String(opt.dates.firstDayOfLastMonth),
+			End:   aws.String(opt.dates.asOf),
+		},
+	})
+	check("ce:GetDimensionValues", "the -allLinkedAccounts flag", err)
+
+	return checks
+}
@@ -0,0 +1,75 @@
+package acos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+var (
+	// ErrNoAccounts is returned when there's no account to retrieve the costs of.
+	ErrNoAccounts = errors.New("error no accounts")
+	// ErrOrganizationNotInUse is returned when the AWS account is not part of an AWS Organizations organization.
+	ErrOrganizationNotInUse = errors.New("error the AWS account is not part of AWS Organizations organization")
+	// ErrCostExplorerNotEnabled is returned when AWS Cost Explorer has never been enabled for the AWS account.
+	ErrCostExplorerNotEnabled = errors.New("error AWS Cost Explorer is not enabled")
+	// ErrBillingAccessNotActivated is returned when IAM users and roles are not allowed to access the billing data.
+	ErrBillingAccessNotActivated = errors.New("error IAM access to the billing data is not activated")
+)
+
+// AccessDeniedError is returned when the caller doesn't have the IAM permission to perform the action.
+type AccessDeniedError struct {
+	Action string // The IAM action, e.g. "ce:GetCostAndUsage".
+	Err    error  // The original error of the AWS API.
+}
+
+func (e *AccessDeniedError) Error() string {
+	return fmt.Sprintf("error access denied to \"%s\": %s", e.Action, e.Err.Error())
+}
+
+func (e *AccessDeniedError) Unwrap() error {
+	return e.Err
+}
+
+// OuNotFoundError is returned when the AWS Organizations OU or Root doesn't exist.
+type OuNotFoundError struct {
+	OuId string
+	Err  error // The original error of the AWS API.
+}
+
+func (e *OuNotFoundError) Error() string {
+	return fmt.Sprintf("error the OU \"%s\" doesn't exist", e.OuId)
+}
+
+func (e *OuNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// classifyError converts the error of an AWS API call for the IAM `action` into the errors of this package.
+// The returned error still wraps the original error. It returns the error as is when it's not known.
+func classifyError(action string, err error) error {
+	var apiErr smithy.APIError
+	if err == nil || !errors.As(err, &apiErr) {
+		return err
+	}
+	msg := strings.ToLower(apiErr.ErrorMessage())
+	switch apiErr.ErrorCode() {
+	case "AWSOrganizationsNotInUseException":
+		return fmt.Errorf("%w: %w", ErrOrganizationNotInUse, err)
+	case "AccessDeniedException", "AccessDenied", "UnauthorizedOperation":
+		// The Cost Explorer API returns AccessDeniedException with the messages below,
+		// e.g. "User not enabled for cost explorer access",
+		if strings.Contains(msg, "not enabled for cost explorer") {
+			return fmt.Errorf("%w: %w", ErrCostExplorerNotEnabled, err)
+		}
+		// and "IAM user access to billing is not activated" until the root user activates it in the console.
+		// The other messages which mention the billing are the generic access denied errors, e.g. of SCPs.
+		if apiErr.ErrorCode() == "AccessDeniedException" && strings.Contains(msg, "access to billing") && strings.Contains(msg, "not activated") {
+			return fmt.Errorf("%w: %w", ErrBillingAccessNotActivated, err)
+		}
+		return &AccessDeniedError{Action: action, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package acos
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func Test_classifyError(t *testing.T) {
+	apiErr := func(code, msg string) error {
+		return &smithy.GenericAPIError{Code: code, Message: msg}
+	}
+	tests := []struct {
+		name       string
+		err        error
+		wantIs     error
+		wantAction string
+	}{
+		{name: "nil", err: nil},
+		{name: "not an API error", err: errors.New("error boom")},
+		{name: "organization not in use", err: apiErr("AWSOrganizationsNotInUseException", "Your account is not a member of an organization."), wantIs: ErrOrganizationNotInUse},
+		{name: "cost explorer not enabled", err: apiErr("AccessDeniedException", "User not enabled for cost explorer access"), wantIs: ErrCostExplorerNotEnabled},
+		{name: "billing access not activated", err: apiErr("AccessDeniedException", "IAM user access to billing is not activated"), wantIs: ErrBillingAccessNotActivated},
+		{name: "billing access not activated, with another code", err: apiErr("AccessDenied", "IAM user access to billing is not activated"), wantAction: "ce:GetCostAndUsage"},
+		{name: "access denied mentioning billing", err: apiErr("AccessDeniedException", "User: arn:aws:iam::123456789012:user/test is not authorized to access the billing data with an explicit deny in a service control policy"), wantAction: "ce:GetCostAndUsage"},
+		{name: "access denied", err: apiErr("AccessDeniedException", "User: arn:aws:iam::123456789012:user/test is not authorized to perform: ce:GetCostAndUsage"), wantAction: "ce:GetCostAndUsage"},
+		{name: "access denied by STS", err: apiErr("AccessDenied", "not authorized"), wantAction: "ce:GetCostAndUsage"},
+		{name: "other API error", err: apiErr("ThrottlingException", "Rate exceeded")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError("ce:GetCostAndUsage", tt.err)
+			if tt.wantIs != nil && !errors.Is(got, tt.wantIs) {
+				t.Errorf("classifyError() = %v, want %v", got, tt.wantIs)
+			}
+			var accessDenied *AccessDeniedError
+			if isAccessDenied := errors.As(got, &accessDenied); isAccessDenied != (len(tt.wantAction) > 0) {
+				t.Errorf("classifyError() = %v, want AccessDeniedError %v", got, len(tt.wantAction) > 0)
+			} else if isAccessDenied && accessDenied.Action != tt.wantAction {
+				t.Errorf("classifyError() action = %v, want %v", accessDenied.Action, tt.wantAction)
+			}
+			if tt.wantIs == nil && len(tt.wantAction) == 0 && got != tt.err {
+				t.Errorf("classifyError() = %v, want the original error", got)
+			}
+			if tt.wantIs != ErrBillingAccessNotActivated && errors.Is(got, ErrBillingAccessNotActivated) {
+				t.Errorf("classifyError() = %v, want not %v", got, ErrBillingAccessNotActivated)
+			}
+			// The original error is always kept.
+			if tt.err != nil && !errors.Is(got, tt.err) {
+				t.Errorf("classifyError() = %v, doesn't wrap %v", got, tt.err)
+			}
+		})
+	}
+}
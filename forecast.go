@@ -0,0 +1,98 @@
+package acos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+type CeGetCostForecastAPI interface {
+	GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error)
+}
+
+var (
+	// AWS clients
+	ceForecastClient CeGetCostForecastAPI
+)
+
+func init() {
+	ceForecastClient = costexplorer.NewFromConfig(cfg)
+}
+
+const (
+	ceForecastGranularity = types.GranularityMonthly
+	ceForecastMetric      = types.MetricUnblendedCost
+)
+
+// fillForecasts fills the "ForecastThisMonth" field of the costs.
+//
+// The AWS Cost Explorer's GetCostForecast API doesn't support grouping, so that it calls the API for each account.
+// It leaves the field nil when the forecast is not available, e.g. when the account doesn't have enough historical data,
+// or when the "asOf" date is in the past. It returns the errors of the accounts for which the API failed.
+func fillForecasts(ctx context.Context, costs Costs, opt AcosGetCostsOption) map[string]error {
+	if !canForecast(opt) {
+		return nil
+	}
+	failures := make(map[string]error)
+	for id, c := range costs {
+		forecast, err := getForecast(ctx, id, opt)
+		if err != nil {
+			failures[id] = fmt.Errorf("error failed to get the forecast: %w", err)
+			continue
+		}
+		if forecast != nil {
+			// The forecast only covers the rest of this month.
+			f := c.AmountThisMonth + *forecast
+			c.ForecastThisMonth = &f
+		}
+		costs[id] = c
+	}
+	return failures
+}
+
+// getForecast returns the forecasted cost of the given account from the "asOf" date to the end of this month.
+// It returns nil when the forecast is not available.
+func getForecast(ctx context.Context, accountId string, opt AcosGetCostsOption) (*float64, error) {
+	in := forecastInput(opt, accountId)
+	out, err := ceForecastClient.GetCostForecast(ctx, &in)
+	if err != nil {
+		var errType *types.DataUnavailableException
+		if errors.As(err, &errType) {
+			return nil, nil
+		}
+		return nil, classifyError("ce:GetCostForecast", err)
+	}
+	if out.Total == nil || out.Total.Amount == nil {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(*out.Total.Amount, 64)
+	if err != nil {
+		return nil, nil
+	}
+	return &f, nil
+}
+
+// forecastInput returns the GetCostForecastInput param for the account from the "asOf" date to the end of this month.
+func forecastInput(opt AcosGetCostsOption, accountId string) costexplorer.GetCostForecastInput {
+	return costexplorer.GetCostForecastInput{
+		Granularity: ceForecastGranularity,
+		Metric:      ceForecastMetric,
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(opt.dates.asOf),
+			End:   aws.String(opt.dates.firstDayOfNextMonth),
+		},
+		Filter: acosOptToFilter(opt, []string{accountId}),
+	}
+}
+
+// canForecast returns false when the "asOf" date is in the past, since the GetCostForecast API doesn't accept it.
+func canForecast(opt AcosGetCostsOption) bool {
+	return opt.dates.asOf >= time.Now().UTC().Format("2006-01-02")
+}
@@ -0,0 +1,262 @@
+package acos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+// LineItem represents a cost of an account for a usage on a day, e.g. a line item of the Cost and Usage Report.
+type LineItem struct {
+	AccountID   string
+	AccountName string
+	Date        string // The usage start date in UTC. The format is "YYYY-MM-DD".
+	RecordType  string // The RECORD_TYPE dimension value of Cost Explorer, e.g. "Usage", "Credit" and "Refund".
+	Service     string
+	UsageType   string
+	Amount      float64 // The unblended cost in USD.
+	// Optional - The payer account of the consolidated billing, e.g. the management account of the organization.
+	PayerAccountID string
+}
+
+// dimension returns the value of the Cost Explorer dimension of the line item.
+func (li LineItem) dimension(key string) (string, error) {
+	switch key {
+	case string(types.DimensionLinkedAccount):
+		return li.AccountID, nil
+	case string(types.DimensionRecordType):
+		return li.RecordType, nil
+	case string(types.DimensionService):
+		return li.Service, nil
+	case string(types.DimensionUsageType):
+		return li.UsageType, nil
+	}
+	return "", fmt.Errorf("error the dimension '%s' is not supported by the line items", key)
+}
+
+// LineItemSource is a DataSource which computes the responses of the Cost Explorer APIs from the line items in memory,
+// e.g. of the Cost and Usage Report files. See LoadCurFiles.
+//
+// It supports the UnblendedCost metric, the DAILY and MONTHLY granularities, the filters by the dimensions of LineItem
+// with the And, Or and Not expressions, and grouping by those dimensions. It returns all the results in a single page,
+// and it never forecasts the costs.
+type LineItemSource struct {
+	items []LineItem
+}
+
+// NewLineItemSource returns the data source of the line items.
+func NewLineItemSource(items []LineItem) *LineItemSource {
+	return &LineItemSource{items: items}
+}
+
+// Accounts returns the accounts in the line items. The name of an account is its ID when the line items don't have it.
+func (s *LineItemSource) Accounts() Accounts {
+	accnts := make(Accounts)
+	for _, li := range s.items {
+		a, ok := accnts[li.AccountID]
+		if !ok || (len(li.AccountName) > 0 && *a.Name == li.AccountID) {
+			name := li.AccountName
+			if len(name) == 0 {
+				name = li.AccountID
+			}
+			accnts[li.AccountID] = Account{Id: aws.String(li.AccountID), Name: aws.String(name)}
+		}
+	}
+	return accnts
+}
+
+// PayerAccounts returns the payer accounts of the accounts in the line items, keyed by the account IDs.
+// The accounts are omitted when the line items don't have their payer accounts.
+func (s *LineItemSource) PayerAccounts() map[string]string {
+	payers := make(map[string]string)
+	for _, li := range s.items {
+		if len(li.PayerAccountID) > 0 {
+			payers[li.AccountID] = li.PayerAccountID
+		}
+	}
+	return payers
+}
+
+func (s *LineItemSource) GetCostAndUsage(ctx context.Context, params *costexplorer.GetCostAndUsageInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostAndUsageOutput, error) {
+	if params.TimePeriod == nil || params.TimePeriod.Start == nil || params.TimePeriod.End == nil {
+		return nil, fmt.Errorf("error the time period is required")
+	}
+	for _, m := range params.Metrics {
+		if m != ceCostMetric {
+			return nil, fmt.Errorf("error the metric '%s' is not supported by the line items", m)
+		}
+	}
+	periods, err := timePeriods(*params.TimePeriod.Start, *params.TimePeriod.End, params.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range params.GroupBy {
+		if g.Type != types.GroupDefinitionTypeDimension || g.Key == nil {
+			return nil, fmt.Errorf("error only the dimensions are supported to group the line items")
+		}
+	}
+
+	// map[period index]map[group key]amount
+	sums := make([]map[string]float64, len(periods))
+	for i := range sums {
+		sums[i] = make(map[string]float64)
+	}
+	for _, li := range s.items {
+		i := sort.Search(len(periods), func(i int) bool { return periods[i][1] > li.Date })
+		if i == len(periods) || li.Date < periods[i][0] {
+			continue
+		}
+		if params.Filter != nil {
+			ok, err := matchExpression(li, *params.Filter)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		keys := make([]string, len(params.GroupBy))
+		for j, g := range params.GroupBy {
+			if keys[j], err = li.dimension(*g.Key); err != nil {
+				return nil, err
+			}
+		}
+		sums[i][strings.Join(keys, "\x00")] += li.Amount
+	}
+
+	out := &costexplorer.GetCostAndUsageOutput{}
+	for i, p := range periods {
+		r := types.ResultByTime{
+			TimePeriod: &types.DateInterval{Start: aws.String(p[0]), End: aws.String(p[1])},
+			Estimated:  false,
+		}
+		if len(params.GroupBy) == 0 {
+			r.Total = map[string]types.MetricValue{ceCostMetric: metricValue(sums[i][""])}
+		} else {
+			keys := make([]string, 0, len(sums[i]))
+			for k := range sums[i] {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				r.Groups = append(r.Groups, types.Group{
+					Keys:    strings.Split(k, "\x00"),
+					Metrics: map[string]types.MetricValue{ceCostMetric: metricValue(sums[i][k])},
+				})
+			}
+		}
+		out.ResultsByTime = append(out.ResultsByTime, r)
+	}
+	return out, nil
+}
+
+// GetCostForecast always returns DataUnavailableException, because the line items don't have enough data to forecast.
+func (s *LineItemSource) GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error) {
+	return nil, &types.DataUnavailableException{Message: aws.String("the line items are not supported to forecast the costs")}
+}
+
+// GetDimensionValues returns the linked accounts in the line items, with their names in the "description" attribute.
+func (s *LineItemSource) GetDimensionValues(ctx context.Context, params *costexplorer.GetDimensionValuesInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetDimensionValuesOutput, error) {
+	if params.Dimension != types.DimensionLinkedAccount {
+		return nil, fmt.Errorf("error the dimension '%s' is not supported by the line items", params.Dimension)
+	}
+	accnts := s.Accounts()
+	out := &costexplorer.GetDimensionValuesOutput{}
+	for _, id := range sortedKeysOf(accnts) {
+		out.DimensionValues = append(out.DimensionValues, types.DimensionValuesWithAttributes{
+			Value:      aws.String(id),
+			Attributes: map[string]string{ceAccountNameAttribute: *accnts[id].Name},
+		})
+	}
+	return out, nil
+}
+
+func sortedKeysOf(accnts Accounts) []string {
+	ids := accnts.AccountIds()
+	sort.Strings(ids)
+	return ids
+}
+
+func metricValue(amount float64) types.MetricValue {
+	return types.MetricValue{Amount: aws.String(strconv.FormatFloat(amount, 'f', -1, 64)), Unit: aws.String("USD")}
+}
+
+// timePeriods returns the [start, end) periods of the granularity between the dates, in the "YYYY-MM-DD" format.
+// The MONTHLY periods are clipped by the dates as the Cost Explorer API does.
+func timePeriods(start, end string, granularity types.Granularity) ([][2]string, error) {
+	s, err1 := time.Parse("2006-01-02", start)
+	e, err2 := time.Parse("2006-01-02", end)
+	if err1 != nil || err2 != nil || !s.Before(e) {
+		return nil, fmt.Errorf("error invalid time period %s - %s", start, end)
+	}
+	var periods [][2]string
+	for p := s; p.Before(e); {
+		var next time.Time
+		switch granularity {
+		case types.GranularityDaily:
+			next = p.AddDate(0, 0, 1)
+		case types.GranularityMonthly:
+			next = time.Date(p.Year(), p.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		default:
+			return nil, fmt.Errorf("error the granularity '%s' is not supported by the line items", granularity)
+		}
+		if next.After(e) {
+			next = e
+		}
+		periods = append(periods, [2]string{p.Format("2006-01-02"), next.Format("2006-01-02")})
+		p = next
+	}
+	return periods, nil
+}
+
+// matchExpression returns true when the line item matches the filter expression.
+// It fails for the expressions which Cost Explorer rejects, i.e. the ones with more than one operator.
+func matchExpression(li LineItem, e types.Expression) (bool, error) {
+	operators := 0
+	for _, set := range []bool{len(e.And) > 0, len(e.Or) > 0, e.Not != nil, e.Dimensions != nil, e.Tags != nil, e.CostCategories != nil} {
+		if set {
+			operators++
+		}
+	}
+	if operators > 1 {
+		return false, fmt.Errorf("error the filter expression must have only one of And, Or, Not, Dimensions, Tags and CostCategories")
+	}
+	switch {
+	case len(e.And) > 0:
+		for _, sub := range e.And {
+			if ok, err := matchExpression(li, sub); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case len(e.Or) > 0:
+		for _, sub := range e.Or {
+			if ok, err := matchExpression(li, sub); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case e.Not != nil:
+		ok, err := matchExpression(li, *e.Not)
+		return !ok, err
+	case e.Dimensions != nil:
+		v, err := li.dimension(string(e.Dimensions.Key))
+		if err != nil {
+			return false, err
+		}
+		for _, want := range e.Dimensions.Values {
+			if v == want {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("error the filter expression is not supported by the line items")
+}
@@ -0,0 +1,49 @@
+package acos
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+)
+
+func Test_matchExpression(t *testing.T) {
+	li := LineItem{AccountID: "111111111111", RecordType: "Usage", Service: "Amazon EC2"}
+	account := func(id string) types.Expression {
+		return types.Expression{Dimensions: &types.DimensionValues{Key: types.DimensionLinkedAccount, Values: []string{id}}}
+	}
+	credit := types.Expression{Dimensions: &types.DimensionValues{Key: types.DimensionRecordType, Values: []string{"Credit"}}}
+	tests := []struct {
+		name    string
+		e       types.Expression
+		want    bool
+		wantErr string
+	}{
+		{name: "dimension", e: account("111111111111"), want: true},
+		{name: "another dimension value", e: account("222222222222"), want: false},
+		{name: "and", e: types.Expression{And: []types.Expression{account("111111111111"), {Not: &credit}}}, want: true},
+		{name: "or", e: types.Expression{Or: []types.Expression{account("222222222222"), credit}}, want: false},
+		{name: "not", e: types.Expression{Not: &credit}, want: true},
+		{
+			name:    "dimensions and and",
+			e:       types.Expression{Dimensions: account("222222222222").Dimensions, And: []types.Expression{account("111111111111"), {Not: &credit}}},
+			wantErr: "only one of",
+		},
+		{name: "empty", e: types.Expression{}, wantErr: "not supported"},
+		{name: "unsupported dimension", e: types.Expression{Dimensions: &types.DimensionValues{Key: types.DimensionRegion}}, wantErr: "REGION"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchExpression(li, tt.e)
+			if len(tt.wantErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("matchExpression() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("matchExpression() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package acos
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CostMatrix represents the costs of accounts by periods, such as days and ISO weeks.
+type CostMatrix struct {
+	Periods      []string // The format is "YYYY-MM-DD" for days, and "YYYY-Www" for ISO weeks.
+	Rows         []CostMatrixRow
+	PeriodTotals []float64 // The total of each period. The order is the same as Periods.
+	Total        float64
+}
+
+// CostMatrixRow represents the costs of an account by periods.
+type CostMatrixRow struct {
+	AccountID   string
+	AccountName string
+	Amounts     []float64 // The order is the same as CostMatrix.Periods.
+	Total       float64
+}
+
+// NewDailyCostMatrix returns the CostMatrix of the given costs by days. The rows are sorted by account ID.
+func NewDailyCostMatrix(costs Costs) CostMatrix {
+	return newCostMatrix(costs, func(date string) string { return date })
+}
+
+// NewWeeklyCostMatrix returns the CostMatrix of the given costs by ISO weeks. The rows are sorted by account ID.
+// Note that the first and the last weeks may not be full weeks.
+func NewWeeklyCostMatrix(costs Costs) CostMatrix {
+	return newCostMatrix(costs, func(date string) string {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return date
+		}
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+}
+
+// newCostMatrix returns the CostMatrix of the given costs. The `period` func returns the period of a date.
+func newCostMatrix(costs Costs, period func(date string) string) CostMatrix {
+	ids := make([]string, 0, len(costs))
+	for id := range costs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	m := CostMatrix{Periods: []string{}, Rows: []CostMatrixRow{}, PeriodTotals: []float64{}}
+	periodIndex := make(map[string]int)
+	for _, id := range ids {
+		for _, d := range costs[id].Daily {
+			p := period(d.Date)
+			if _, ok := periodIndex[p]; !ok {
+				periodIndex[p] = len(m.Periods)
+				m.Periods = append(m.Periods, p)
+			}
+		}
+	}
+	// The daily costs are in chronological order, but they may not be the same among the accounts.
+	sort.Strings(m.Periods)
+	for i, p := range m.Periods {
+		periodIndex[p] = i
+	}
+
+	m.PeriodTotals = make([]float64, len(m.Periods))
+	for _, id := range ids {
+		c := costs[id]
+		r := CostMatrixRow{
+			AccountID:   c.AccountID,
+			AccountName: c.AccountName,
+			Amounts:     make([]float64, len(m.Periods)),
+		}
+		for _, d := range c.Daily {
+			i := periodIndex[period(d.Date)]
+			r.Amounts[i] += d.Amount
+			r.Total += d.Amount
+			m.PeriodTotals[i] += d.Amount
+			m.Total += d.Amount
+		}
+		m.Rows = append(m.Rows, r)
+	}
+	return m
+}
@@ -0,0 +1,60 @@
+package acos
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewCostMatrix(t *testing.T) {
+	costs := Costs{
+		"210987654321": Cost{
+			AccountID:   "210987654321",
+			AccountName: "b",
+			Daily:       []DailyCost{{"2023-07-01", 1}, {"2023-07-02", 2}, {"2023-07-03", 3}},
+		},
+		"123456789012": Cost{
+			AccountID:   "123456789012",
+			AccountName: "a",
+			Daily:       []DailyCost{{"2023-07-01", 10}, {"2023-07-02", 20}, {"2023-07-03", 30}},
+		},
+	}
+	tests := []struct {
+		name string
+		got  CostMatrix
+		want CostMatrix
+	}{
+		{
+			name: "daily",
+			got:  NewDailyCostMatrix(costs),
+			want: CostMatrix{
+				Periods: []string{"2023-07-01", "2023-07-02", "2023-07-03"},
+				Rows: []CostMatrixRow{
+					{AccountID: "123456789012", AccountName: "a", Amounts: []float64{10, 20, 30}, Total: 60},
+					{AccountID: "210987654321", AccountName: "b", Amounts: []float64{1, 2, 3}, Total: 6},
+				},
+				PeriodTotals: []float64{11, 22, 33},
+				Total:        66,
+			},
+		},
+		{
+			name: "weekly", // 2023-07-02 is Sunday, and 2023-07-03 is Monday.
+			got:  NewWeeklyCostMatrix(costs),
+			want: CostMatrix{
+				Periods: []string{"2023-W26", "2023-W27"},
+				Rows: []CostMatrixRow{
+					{AccountID: "123456789012", AccountName: "a", Amounts: []float64{30, 30}, Total: 60},
+					{AccountID: "210987654321", AccountName: "b", Amounts: []float64{3, 3}, Total: 6},
+				},
+				PeriodTotals: []float64{33, 33},
+				Total:        66,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("CostMatrix = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -3,18 +3,35 @@ package acos
 import (
 	"context"
 	"errors"
+	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
 )
 
+type OrgListTagsForResourceAPI interface {
+	ListTagsForResource(ctx context.Context, params *organizations.ListTagsForResourceInput, optFns ...func(*organizations.Options)) (*organizations.ListTagsForResourceOutput, error)
+}
+
+// OrganizationsAPI represents the AWS Organizations APIs used by acos.
+type OrganizationsAPI interface {
+	ListAccounts(ctx context.Context, params *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error)
+	ListAccountsForParent(ctx context.Context, params *organizations.ListAccountsForParentInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsForParentOutput, error)
+	ListParents(ctx context.Context, params *organizations.ListParentsInput, optFns ...func(*organizations.Options)) (*organizations.ListParentsOutput, error)
+	DescribeOrganizationalUnit(ctx context.Context, params *organizations.DescribeOrganizationalUnitInput, optFns ...func(*organizations.Options)) (*organizations.DescribeOrganizationalUnitOutput, error)
+	OrgListTagsForResourceAPI
+}
+
 var (
 	// AWS clients
-	organizationsClient *organizations.Client
+	organizationsClient OrganizationsAPI
+	orgListTagsClient   OrgListTagsForResourceAPI
 )
 
 func init() {
 	organizationsClient = organizations.NewFromConfig(cfg)
+	orgListTagsClient = organizationsClient
 }
 
 // Account wraps up AWS Organization Account struct.
@@ -45,7 +62,7 @@ func ListAccounts(ctx context.Context) (Accounts, error) {
 			},
 		)
 		if err != nil {
-			return nil, err
+			return nil, classifyError("organizations:ListAccounts", err)
 		}
 		for _, acc := range out.Accounts {
 			accnts[*acc.Id] = Account(acc)
@@ -70,8 +87,11 @@ func ListAccountsByOu(ctx context.Context, ouId string) (Accounts, error) {
 				NextToken: nextToken,
 			},
 		)
-		if err != nil {
-			return nil, err
+		var notFound *types.ParentNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, &OuNotFoundError{OuId: ouId, Err: err}
+		} else if err != nil {
+			return nil, classifyError("organizations:ListAccountsForParent", err)
 		}
 		for _, acc := range out.Accounts {
 			accnts[*acc.Id] = Account(acc)
@@ -84,16 +104,166 @@ func ListAccountsByOu(ctx context.Context, ouId string) (Accounts, error) {
 	return accnts, nil
 }
 
+// Ou represents an AWS Organization Organizational Unit (OU) or Root.
+type Ou struct {
+	Id   string
+	Name string
+}
+
+// AccountOus represents a map of the parent Ou of accounts. The map key is the account ID.
+type AccountOus map[string]Ou // map[accountId]Ou
+
+// ListAccountOus returns the direct-parent OU of each given account.
+// The name of a Root is always "Root".
+func ListAccountOus(ctx context.Context, accountIds []string) (AccountOus, error) {
+	names := make(map[string]string) // map[ouId]name to avoid describing the same OU repeatedly
+	ous := make(AccountOus, len(accountIds))
+	for _, id := range accountIds {
+		out, err := organizationsClient.ListParents(
+			ctx,
+			&organizations.ListParentsInput{
+				ChildId: aws.String(id),
+			},
+		)
+		if err != nil {
+			return nil, classifyError("organizations:ListParents", err)
+		}
+		if len(out.Parents) == 0 {
+			continue
+		}
+		parent := out.Parents[0] // An account has only one parent.
+		if _, ok := names[*parent.Id]; !ok {
+			if parent.Type == types.ParentTypeRoot {
+				names[*parent.Id] = "Root"
+			} else {
+				ou, err := organizationsClient.DescribeOrganizationalUnit(
+					ctx,
+					&organizations.DescribeOrganizationalUnitInput{
+						OrganizationalUnitId: parent.Id,
+					},
+				)
+				if err != nil {
+					return nil, classifyError("organizations:DescribeOrganizationalUnit", err)
+				}
+				names[*parent.Id] = *ou.OrganizationalUnit.Name
+			}
+		}
+		ous[id] = Ou{Id: *parent.Id, Name: names[*parent.Id]}
+	}
+	return ous, nil
+}
+
+// AccountTags represents a map of the tags of accounts. The map key is the account ID.
+type AccountTags map[string]map[string]string // map[accountId]map[tagKey]tagValue
+
+const listTagsConcurrency = 5 // The number of the concurrent ListTagsForResource calls.
+
+// ListAccountTags returns the tags of each given account.
+//
+// The AWS Organizations ListTagsForResource API accepts only one account at a time, so that it calls the API
+// for the accounts concurrently. The calls throttled by the API are retried by the clients. See Configure.
+func ListAccountTags(ctx context.Context, accountIds []string) (AccountTags, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		accountId string
+		tags      map[string]string
+		err       error
+	}
+	ids := make(chan string)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for i := 0; i < listTagsConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				tags, err := listTags(ctx, id)
+				results <- result{accountId: id, tags: tags, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(ids)
+		for _, id := range accountIds {
+			select {
+			case ids <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	tags := make(AccountTags, len(accountIds))
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+				cancel() // Stop the remaining calls.
+			}
+			continue
+		}
+		tags[r.accountId] = r.tags
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return tags, nil
+}
+
+// listTags returns the tags of the account.
+func listTags(ctx context.Context, accountId string) (map[string]string, error) {
+	var nextToken *string
+	tags := make(map[string]string)
+	for {
+		out, err := orgListTagsClient.ListTagsForResource(
+			ctx,
+			&organizations.ListTagsForResourceInput{
+				ResourceId: aws.String(accountId),
+				NextToken:  nextToken,
+			},
+		)
+		if err != nil {
+			return nil, classifyError("organizations:ListTagsForResource", err)
+		}
+		for _, t := range out.Tags {
+			tags[*t.Key] = *t.Value
+		}
+		nextToken = out.NextToken
+		if nextToken == nil {
+			return tags, nil
+		}
+	}
+}
+
+// IsOrganizationEnabled returns false if the error is caused by the account which is not part of an organization.
+// Note that it returns true for any other error, including nil.
+//
+// Deprecated: Use errors.Is(err, ErrOrganizationNotInUse) instead.
 func IsOrganizationEnabled(err error) bool {
 	var errType *types.AWSOrganizationsNotInUseException
 	return !errors.As(err, &errType)
 }
 
+// HasPermissionToOrganizationsApi returns false if the error is caused by the lack of the IAM permission to AWS Organizations.
+// Note that it returns true for any other error, including nil.
+//
+// Deprecated: Use errors.As with *AccessDeniedError instead.
 func HasPermissionToOrganizationsApi(err error) bool {
 	var errType *types.AccessDeniedException
 	return !errors.As(err, &errType)
 }
 
+// OuExists returns false if the error is caused by the OU which doesn't exist.
+// Note that it returns true for any other error, including nil.
+//
+// Deprecated: Use errors.As with *OuNotFoundError instead.
 func OuExists(err error) bool {
 	var errType *types.ParentNotFoundException
 	return !errors.As(err, &errType)
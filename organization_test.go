@@ -1,8 +1,15 @@
 package acos
 
 import (
+	"context"
+	"errors"
 	"reflect"
+	"sync"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
 )
 
 func toPointer(s string) *string {
@@ -39,3 +46,62 @@ func TestAccounts_AccountIds(t *testing.T) {
 		})
 	}
 }
+
+type mockListTagsForResourceAPI func(ctx context.Context, params *organizations.ListTagsForResourceInput, optFns ...func(*organizations.Options)) (*organizations.ListTagsForResourceOutput, error)
+
+func (m mockListTagsForResourceAPI) ListTagsForResource(ctx context.Context, params *organizations.ListTagsForResourceInput, optFns ...func(*organizations.Options)) (*organizations.ListTagsForResourceOutput, error) {
+	return m(ctx, params, optFns...)
+}
+
+func TestWithMock_ListAccountTags(t *testing.T) {
+	defer func(c OrgListTagsForResourceAPI) { orgListTagsClient = c }(orgListTagsClient)
+
+	tag := func(k, v string) orgtypes.Tag { return orgtypes.Tag{Key: aws.String(k), Value: aws.String(v)} }
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	orgListTagsClient = mockListTagsForResourceAPI(func(ctx context.Context, params *organizations.ListTagsForResourceInput, optFns ...func(*organizations.Options)) (*organizations.ListTagsForResourceOutput, error) {
+		mu.Lock()
+		calls[*params.ResourceId]++
+		n := calls[*params.ResourceId]
+		mu.Unlock()
+		switch *params.ResourceId {
+		case "111111111111":
+			return &organizations.ListTagsForResourceOutput{Tags: []orgtypes.Tag{tag("env", "prod")}}, nil
+		case "222222222222":
+			// Paginated.
+			if params.NextToken == nil {
+				return &organizations.ListTagsForResourceOutput{Tags: []orgtypes.Tag{tag("env", "dev")}, NextToken: aws.String("next")}, nil
+			}
+			return &organizations.ListTagsForResourceOutput{Tags: []orgtypes.Tag{tag("owner", "alice")}}, nil
+		case "444444444444":
+			// The throttled calls are retried by the SDK retryer of the client, not by ListAccountTags.
+			if n > 1 {
+				t.Errorf("ListAccountTags() retried the throttled call")
+			}
+			return nil, &orgtypes.TooManyRequestsException{}
+		case "999999999999":
+			return nil, &orgtypes.AccountNotFoundException{}
+		}
+		return &organizations.ListTagsForResourceOutput{}, nil
+	})
+
+	got, err := ListAccountTags(context.Background(), []string{"111111111111", "222222222222", "333333333333"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AccountTags{
+		"111111111111": {"env": "prod"},
+		"222222222222": {"env": "dev", "owner": "alice"},
+		"333333333333": {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListAccountTags() = %v, want %v", got, want)
+	}
+	if _, err := ListAccountTags(context.Background(), []string{"111111111111", "999999999999"}); err == nil {
+		t.Errorf("ListAccountTags() should fail when the API fails")
+	}
+	var throttled *orgtypes.TooManyRequestsException
+	if _, err := ListAccountTags(context.Background(), []string{"444444444444"}); !errors.As(err, &throttled) {
+		t.Errorf("ListAccountTags() error = %v, want TooManyRequestsException", err)
+	}
+}
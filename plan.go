@@ -0,0 +1,126 @@
+package acos
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// CeRequestPrice is the price of a paginated AWS Cost Explorer API request in USD.
+	CeRequestPrice = 0.01
+
+	// ceEstimatedGroupsPerPage is the estimated number of groups in a page of the GetCostAndUsage API.
+	// The page size isn't documented, so that it's only used to estimate the number of the paginated requests.
+	ceEstimatedGroupsPerPage = 1000
+
+	// ceEstimatedServicesPerAccount is the estimated number of the services which an account uses in a day.
+	ceEstimatedServicesPerAccount = 10
+)
+
+// PlannedRequest represents an AWS API request which GetCosts would send.
+type PlannedRequest struct {
+	Action string // The IAM action, e.g. "ce:GetCostAndUsage".
+	Input  any    // The input of the first page, e.g. costexplorer.GetCostAndUsageInput.
+	Pages  int    // The estimated number of the pages, i.e. the requests.
+	Note   string // Why the request may or may not be sent, or empty.
+}
+
+// CostsPlan represents the AWS API requests which GetCosts would send.
+type CostsPlan []PlannedRequest
+
+// Requests returns the estimated number of the requests including the pages.
+func (p CostsPlan) Requests() int {
+	n := 0
+	for _, r := range p {
+		n += r.Pages
+	}
+	return n
+}
+
+// Price returns the estimated price of the requests in USD. Only the Cost Explorer API requests are charged.
+func (p CostsPlan) Price() float64 {
+	n := 0
+	for _, r := range p {
+		if strings.HasPrefix(r.Action, "ce:") {
+			n += r.Pages
+		}
+	}
+	return float64(n) * CeRequestPrice
+}
+
+// PlanGetCosts returns the AWS API requests which GetCosts would send for the accounts and the options,
+// without calling any AWS API. The numbers of the pages are estimated from the numbers of the accounts and the days.
+func PlanGetCosts(accounts Accounts, opt AcosGetCostsOption) CostsPlan {
+	accountIds := accounts.AccountIds()
+	if opt.AllLinkedAccounts {
+		accountIds = nil
+	}
+	plan := planCostAndUsage(accountIds, len(accounts), 1, opt, func(batch []string) any {
+		return acosOptToCostExplorerOpt(opt, batch)
+	})
+	if opt.AllLinkedAccounts {
+		plan = append(plan, PlannedRequest{
+			Action: "ce:GetDimensionValues",
+			Input:  linkedAccountsInput(opt),
+			Pages:  1,
+			Note:   "only sent when there are accounts which are not in the organization",
+		})
+	}
+	if opt.IncludeForecast && canForecast(opt) {
+		ids := accounts.AccountIds()
+		sort.Strings(ids)
+		for _, id := range ids {
+			plan = append(plan, PlannedRequest{
+				Action: "ce:GetCostForecast",
+				Input:  forecastInput(opt, id),
+				Pages:  1,
+			})
+		}
+	}
+	return plan
+}
+
+// PlanGetServiceCosts returns the AWS API requests which GetServiceCosts would send for the accounts and the options,
+// without calling any AWS API. The numbers of the pages are estimated from the numbers of the accounts and the days,
+// assuming that each account uses 10 services a day.
+func PlanGetServiceCosts(accounts Accounts, opt AcosGetCostsOption) CostsPlan {
+	accountIds := accounts.AccountIds()
+	if opt.AllLinkedAccounts {
+		accountIds = nil
+	}
+	return planCostAndUsage(accountIds, len(accounts), ceEstimatedServicesPerAccount, opt, func(batch []string) any {
+		return serviceCostsInput(opt, batch)
+	})
+}
+
+// planCostAndUsage returns the GetCostAndUsage requests for the batches of the account IDs, which have the groups
+// for each account and day. Empty `accountIds` means all the linked accounts, which are estimated by `numAccounts`.
+func planCostAndUsage(accountIds []string, numAccounts, groupsPerAccount int, opt AcosGetCostsOption, input func(batch []string) any) CostsPlan {
+	days := daysBetween(opt.dates.firstDayOfLastMonth, opt.dates.asOf)
+	if opt.monthly {
+		// A group for each account.
+		days = 1
+	}
+	var plan CostsPlan
+	for _, batch := range splitAccountIds(accountIds, ceAccountBatchSize) {
+		r := PlannedRequest{
+			Action: "ce:GetCostAndUsage",
+			Input:  input(batch),
+			Pages:  estimatePages(len(batch)*days*groupsPerAccount, ceEstimatedGroupsPerPage),
+		}
+		if batch == nil {
+			r.Pages = estimatePages(numAccounts*days*groupsPerAccount, ceEstimatedGroupsPerPage)
+			r.Note = "the number of the pages depends on the number of the linked accounts"
+		}
+		plan = append(plan, r)
+	}
+	return plan
+}
+
+// estimatePages returns the number of the pages to get the items, which is at least 1.
+func estimatePages(items, perPage int) int {
+	if items <= perPage {
+		return 1
+	}
+	return (items + perPage - 1) / perPage
+}
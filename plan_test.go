@@ -0,0 +1,113 @@
+package acos
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+)
+
+func TestPlanGetCosts(t *testing.T) {
+	accountsOf := func(n int) Accounts {
+		accounts := make(Accounts, n)
+		for i := 0; i < n; i++ {
+			id := fmt.Sprintf("%012d", i+1)
+			accounts[id] = Account{Id: toPointer(id), Name: toPointer("account-" + id)}
+		}
+		return accounts
+	}
+	// 2023-06-01 to 2023-07-18 is 47 days.
+	past := NewGetCostsOption(time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC))
+	// A future date keeps canForecast true regardless of when the test runs.
+	withForecast := NewGetCostsOption(time.Date(2099, 7, 18, 0, 0, 0, 0, time.UTC))
+	withForecast.IncludeForecast = true
+	allLinked := past
+	allLinked.AllLinkedAccounts = true
+
+	tests := []struct {
+		name         string
+		accounts     Accounts
+		opt          AcosGetCostsOption
+		wantActions  []string
+		wantRequests int
+	}{
+		{
+			name:         "single batch",
+			accounts:     accountsOf(10),
+			opt:          past,
+			wantActions:  []string{"ce:GetCostAndUsage"},
+			wantRequests: 1,
+		},
+		{
+			// 100 accounts * 47 days = 4,700 groups = 5 pages for the first two batches, and 50 * 47 = 2,350 groups = 3 pages for the last.
+			name:         "batches and pages",
+			accounts:     accountsOf(250),
+			opt:          past,
+			wantActions:  []string{"ce:GetCostAndUsage", "ce:GetCostAndUsage", "ce:GetCostAndUsage"},
+			wantRequests: 13,
+		},
+		{
+			name:         "forecast for each account",
+			accounts:     accountsOf(2),
+			opt:          withForecast,
+			wantActions:  []string{"ce:GetCostAndUsage", "ce:GetCostForecast", "ce:GetCostForecast"},
+			wantRequests: 3,
+		},
+		{
+			name:         "all linked accounts",
+			accounts:     accountsOf(250),
+			opt:          allLinked,
+			wantActions:  []string{"ce:GetCostAndUsage", "ce:GetDimensionValues"},
+			wantRequests: 13,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := PlanGetCosts(tt.accounts, tt.opt)
+			var actions []string
+			for _, r := range plan {
+				actions = append(actions, r.Action)
+			}
+			if fmt.Sprint(actions) != fmt.Sprint(tt.wantActions) {
+				t.Errorf("actions = %v, want %v", actions, tt.wantActions)
+			}
+			if got := plan.Requests(); got != tt.wantRequests {
+				t.Errorf("Requests() = %d, want %d", got, tt.wantRequests)
+			}
+			if got, want := plan.Price(), float64(tt.wantRequests)*CeRequestPrice; math.Abs(got-want) > 1e-9 {
+				t.Errorf("Price() = %f, want %f", got, want)
+			}
+		})
+	}
+
+	t.Run("inputs are the same as GetCosts", func(t *testing.T) {
+		plan := PlanGetCosts(accountsOf(150), past)
+		in, ok := plan[1].Input.(costexplorer.GetCostAndUsageInput)
+		if !ok {
+			t.Fatalf("Input = %T, want costexplorer.GetCostAndUsageInput", plan[1].Input)
+		}
+		ids := filterAccountIds(&in)
+		if len(ids) != 50 || ids[0] != "000000000101" {
+			t.Errorf("the second batch = %d accounts from %v, want 50 accounts from 000000000101", len(ids), ids[0])
+		}
+	})
+}
+
+func TestPlanGetServiceCosts(t *testing.T) {
+	accounts := make(Accounts, 10)
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("%012d", i+1)
+		accounts[id] = Account{Id: toPointer(id), Name: toPointer("account-" + id)}
+	}
+	// 10 accounts * 47 days * 10 services = 4,700 groups = 5 pages.
+	plan := PlanGetServiceCosts(accounts, NewGetCostsOption(time.Date(2023, 7, 18, 0, 0, 0, 0, time.UTC)))
+	if len(plan) != 1 || plan.Requests() != 5 {
+		t.Fatalf("PlanGetServiceCosts() = %d requests in %d batches, want 5 in 1", plan.Requests(), len(plan))
+	}
+	in := plan[0].Input.(costexplorer.GetCostAndUsageInput)
+	if len(in.GroupBy) != 2 || *in.GroupBy[1].Key != BreakdownByService {
+		t.Errorf("PlanGetServiceCosts() doesn't group by the services")
+	}
+}
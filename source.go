@@ -0,0 +1,17 @@
+package acos
+
+// DataSource represents the source of the cost data, which serves the Cost Explorer APIs used by acos.
+// The AWS Cost Explorer API is the default data source. See LineItemSource for the alternate one.
+type DataSource interface {
+	CeGetCostAndUsageAPI
+	CeGetCostForecastAPI
+	CeGetDimensionValuesAPI
+}
+
+// UseDataSource retrieves the costs from the data source instead of the AWS Cost Explorer API.
+// It should be called after Configure and EnableRequestCounter, and before EnableCache.
+func UseDataSource(s DataSource) {
+	ceClient = s
+	ceForecastClient = s
+	ceDimensionClient = s
+}
@@ -7,10 +7,18 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
+type StsGetCallerIdentityAPI interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+type IamListAccountAliasesAPI interface {
+	ListAccountAliases(ctx context.Context, params *iam.ListAccountAliasesInput, optFns ...func(*iam.Options)) (*iam.ListAccountAliasesOutput, error)
+}
+
 var (
 	// AWS clients
-	stsClient *sts.Client
-	iamClient *iam.Client
+	stsClient StsGetCallerIdentityAPI
+	iamClient IamListAccountAliasesAPI
 )
 
 func init() {
@@ -21,15 +29,15 @@ func init() {
 // GetCallerAccount returns the account ID and name for the current user session.
 func GetCallerAccount(ctx context.Context) ([]string, error) {
 	res := make([]string, 2)
-	out, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	id, err := GetCallerAccountId(ctx)
 	if err != nil {
 		return res, err
 	}
-	res[0] = *out.Account // Account ID
+	res[0] = id // Account ID
 	// Try to fetch human-readable account name
 	out2, err := iamClient.ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
 	if err != nil {
-		return res, err
+		return res, classifyError("iam:ListAccountAliases", err)
 	}
 	if len(out2.AccountAliases) > 0 {
 		res[1] = out2.AccountAliases[0] // Alias as account name
@@ -38,3 +46,12 @@ func GetCallerAccount(ctx context.Context) ([]string, error) {
 	}
 	return res, nil
 }
+
+// GetCallerAccountId returns the account ID for the current user session.
+func GetCallerAccountId(ctx context.Context) (string, error) {
+	out, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", classifyError("sts:GetCallerIdentity", err)
+	}
+	return *out.Account, nil
+}